@@ -0,0 +1,15 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps opaque payloads that are too large to live inside a DynamoDB item.
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,45 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// FileBlobStore keeps blobs as files under a root directory. Meant for tests and local development.
+type FileBlobStore struct {
+	root string
+}
+
+func ProvideFileBlobStore(root string) BlobStore {
+	return &FileBlobStore{root: root}
+}
+
+func (store *FileBlobStore) Put(ctx context.Context, key string, data []byte) error {
+	filePath := store.filePath(key)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, data, 0o644)
+}
+
+func (store *FileBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(store.filePath(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return data, err
+}
+
+func (store *FileBlobStore) Delete(ctx context.Context, key string) error {
+	err := os.Remove(store.filePath(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (store *FileBlobStore) filePath(key string) string {
+	return filepath.Join(store.root, filepath.FromSlash(filepath.Clean("/"+key)))
+}
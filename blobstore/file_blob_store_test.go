@@ -0,0 +1,70 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileBlobStoreRoundTrip(t *testing.T) {
+	store := ProvideFileBlobStore(t.TempDir())
+	ctx := context.Background()
+	if err := store.Put(ctx, "table/customer%231/profile", []byte("payload")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	data, err := store.Get(ctx, "table/customer%231/profile")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !bytes.Equal(data, []byte("payload")) {
+		t.Fatalf("Get = %q, want %q", data, "payload")
+	}
+	if err := store.Put(ctx, "table/customer%231/profile", []byte("replaced")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if data, _ := store.Get(ctx, "table/customer%231/profile"); !bytes.Equal(data, []byte("replaced")) {
+		t.Fatalf("Get after overwrite = %q, want %q", data, "replaced")
+	}
+}
+
+func TestFileBlobStoreMissingBlob(t *testing.T) {
+	store := ProvideFileBlobStore(t.TempDir())
+	ctx := context.Background()
+	if _, err := store.Get(ctx, "missing"); !errors.Is(err, ErrBlobNotFound) {
+		t.Fatalf("Get of a missing blob = %v, want ErrBlobNotFound", err)
+	}
+	if err := store.Delete(ctx, "missing"); err != nil {
+		t.Fatalf("Delete of a missing blob = %v, want nil", err)
+	}
+}
+
+func TestFileBlobStoreDelete(t *testing.T) {
+	store := ProvideFileBlobStore(t.TempDir())
+	ctx := context.Background()
+	if err := store.Put(ctx, "a/b", []byte("payload")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := store.Delete(ctx, "a/b"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, "a/b"); !errors.Is(err, ErrBlobNotFound) {
+		t.Fatalf("Get after Delete = %v, want ErrBlobNotFound", err)
+	}
+}
+
+func TestFileBlobStoreStaysUnderRoot(t *testing.T) {
+	root := t.TempDir()
+	store := ProvideFileBlobStore(filepath.Join(root, "blobs"))
+	if err := store.Put(context.Background(), "../../escaped", []byte("payload")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "escaped")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("a key with .. was written outside the root")
+	}
+	if _, err := os.Stat(filepath.Join(root, "blobs", "escaped")); err != nil {
+		t.Fatalf("blob is not under the root: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/nicholaspark09/awsgorocket/config"
+	"io"
+	"path"
+)
+
+type S3BlobStore struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+func ProvideS3BlobStore(provider config.ConfigProvider, bucket string, prefix string) BlobStore {
+	return &S3BlobStore{
+		client: s3.NewFromConfig(provider.SdkConfig, func(options *s3.Options) {
+			options.BaseEndpoint = provider.Environment.BaseEndpoint(config.ServiceS3)
+			// Local stand-ins such as LocalStack do not serve virtual-hosted bucket names.
+			options.UsePathStyle = provider.Environment.HasEndpoint(config.ServiceS3)
+		}),
+		bucket: bucket,
+		prefix: prefix,
+	}
+}
+
+func (store *S3BlobStore) Put(ctx context.Context, key string, data []byte) error {
+	_, err := store.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(store.bucket),
+		Key:    aws.String(path.Join(store.prefix, key)),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+func (store *S3BlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	output, err := store.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(store.bucket),
+		Key:    aws.String(path.Join(store.prefix, key)),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, ErrBlobNotFound
+		}
+		return nil, err
+	}
+	defer output.Body.Close()
+	return io.ReadAll(output.Body)
+}
+
+func (store *S3BlobStore) Delete(ctx context.Context, key string) error {
+	_, err := store.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(store.bucket),
+		Key:    aws.String(path.Join(store.prefix, key)),
+	})
+	return err
+}
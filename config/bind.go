@@ -0,0 +1,134 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	urlType      = reflect.TypeOf(url.URL{})
+)
+
+// Bind fills a struct from its `config:"KEY" default:"..." required:"true"` tags. Untagged struct fields
+// are bound recursively. Every missing, malformed or unresolvable key is reported together in the
+// returned error; defaults only stand in for keys that are not set.
+//
+//	type Settings struct {
+//		Table   string        `config:"TABLE_NAME" required:"true"`
+//		Timeout time.Duration `config:"TIMEOUT" default:"5s"`
+//	}
+func (repo *ConfigRepository) Bind(target any) error {
+	return Bind(repo.live(), target)
+}
+
+func Bind(repository ValueLookup, target any) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return errors.New("bind target must be a pointer to a struct")
+	}
+	var problems []error
+	bindStruct(repository, value.Elem(), &problems)
+	return errors.Join(problems...)
+}
+
+func bindStruct(repository ValueLookup, value reflect.Value, problems *[]error) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key, tagged := field.Tag.Lookup("config")
+		if !tagged {
+			if field.Type.Kind() == reflect.Struct && field.Type != urlType {
+				bindStruct(repository, value.Field(i), problems)
+			}
+			continue
+		}
+		raw, _, found, err := resolveValue(repository, key)
+		if err != nil {
+			*problems = append(*problems, err)
+			continue
+		}
+		if !found {
+			defaultValue, hasDefault := field.Tag.Lookup("default")
+			if !hasDefault {
+				if field.Tag.Get("required") == "true" {
+					*problems = append(*problems, fmt.Errorf("config key %s is required", key))
+				}
+				continue
+			}
+			raw = defaultValue
+		}
+		if err := setField(value.Field(i), raw); err != nil {
+			*problems = append(*problems, fmt.Errorf("config key %s: %w", key, err))
+		}
+	}
+}
+
+func setField(field reflect.Value, raw string) error {
+	switch {
+	case field.Type() == durationType:
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+		return nil
+	case field.Type() == urlType:
+		parsedUrl, err := parseAbsoluteUrl(raw)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(*parsedUrl))
+		return nil
+	case field.Type() == reflect.PointerTo(urlType):
+		parsedUrl, err := parseAbsoluteUrl(raw)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(parsedUrl))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(parsed)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", field.Type())
+		}
+		parts, _ := parseStringSlice(raw)
+		field.Set(reflect.ValueOf(parts).Convert(field.Type()))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"context"
+	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"log/slog"
+	"strings"
 )
 
 var configRepository ConfigRepositoryContract
@@ -10,23 +14,96 @@ var configRepository ConfigRepositoryContract
 type ConfigProvider struct {
 	SdkConfig        aws.Config
 	ConfigRepository ConfigRepositoryContract
+	// Environment is the resolved profile, including every endpoint override in effect.
+	Environment EnvironmentProfile
+	// Logger is handed to what is built from the provider, such as the configured metrics managers. It
+	// may be nil, and then nothing is logged.
+	Logger *slog.Logger
 }
 
+// ProvideConfigProvider uses static credentials when ACCESS_KEY_ID and SECRET_KEY are both set and
+// the standard AWS credential chain otherwise, so roles work on Lambda, ECS and EC2.
+//
+// When the config can't be loaded, every AWS call made with it fails with the load error. Use
+// ProvideConfigProviderWithOptions(OptionsFromRepository(&ConfigRepository{})) to get the error up front.
 func ProvideConfigProvider() ConfigProvider {
+	return ProvideConfigProviderWithLogger(nil)
+}
+
+// ProvideConfigProviderWithLogger is ProvideConfigProvider with a logger that hears about a config that
+// could not be loaded and is kept in the provider's Logger.
+func ProvideConfigProviderWithLogger(logger *slog.Logger) ConfigProvider {
 	configRepository := ConfigRepository{}
-	region := configRepository.GetString("REGION", "us-east-2")
-	accessKey := configRepository.GetString("ACCESS_KEY_ID", "")
-	secretKey := configRepository.GetString("SECRET_KEY", "")
-	sdkConfig := aws.Config{
-		Region: region,
-		Credentials: credentials.NewStaticCredentialsProvider(
-			accessKey,
-			secretKey,
-			"",
-		),
+	configRepository.logger.Store(logger)
+	options := OptionsFromRepository(&configRepository)
+	sdkConfig, environment, err := loadSdkConfig(context.TODO(), options)
+	if err != nil {
+		utils.LoggerOrNop(logger).Warn("Error in loading the AWS config", "error", err)
+		sdkConfig = aws.Config{
+			Region:      options.Region,
+			Credentials: failedCredentials{err: fmt.Errorf("could not load the AWS config: %w", err)},
+		}
+		if len(sdkConfig.Region) == 0 {
+			sdkConfig.Region = defaultRegion
+		}
 	}
 	return ConfigProvider{
 		SdkConfig:        sdkConfig,
 		ConfigRepository: &configRepository,
+		Environment:      environment,
+		Logger:           logger,
+	}
+}
+
+// failedCredentials stands in for the credentials of a config that could not be loaded, so calls fail
+// with the reason instead of going out without credentials.
+type failedCredentials struct {
+	err error
+}
+
+func (credentials failedCredentials) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	return aws.Credentials{}, credentials.err
+}
+
+func ProvideConfigProviderWithOptions(options ConfigProviderOptions) (ConfigProvider, error) {
+	sdkConfig, environment, err := loadSdkConfig(context.TODO(), options)
+	if err != nil {
+		return ConfigProvider{}, err
+	}
+	return ConfigProvider{
+		SdkConfig:        sdkConfig,
+		ConfigRepository: &ConfigRepository{},
+		Environment:      environment,
+	}, nil
+}
+
+// OptionsFromRepository reads REGION, ACCESS_KEY_ID, SECRET_KEY, SESSION_TOKEN, PROFILE, the ASSUME_ROLE_*
+// keys, ENVIRONMENT_PROFILE, ENDPOINT_URL and ENDPOINT_<SERVICE> (e.g. ENDPOINT_DYNAMODB) into options.
+func OptionsFromRepository(repository ConfigRepositoryContract) ConfigProviderOptions {
+	options := ConfigProviderOptions{
+		Region:             repository.GetString("REGION", ""),
+		StaticAccessKeyId:  repository.GetString("ACCESS_KEY_ID", ""),
+		StaticSecretKey:    repository.GetString("SECRET_KEY", ""),
+		StaticSessionToken: repository.GetString("SESSION_TOKEN", ""),
+		Profile:            repository.GetString("PROFILE", ""),
+		Environment:        repository.GetString("ENVIRONMENT_PROFILE", ""),
+		Endpoints:          map[string]string{},
+	}
+	allServices := repository.GetString("ENDPOINT_URL", "")
+	for _, service := range endpointServices {
+		if endpoint := repository.GetString("ENDPOINT_"+strings.ToUpper(service), allServices); len(endpoint) > 0 {
+			options.Endpoints[service] = endpoint
+		}
+	}
+	if len(options.StaticAccessKeyId) > 0 && len(options.StaticSecretKey) > 0 {
+		options.Source = CredentialSourceStatic
+	}
+	if roleArn := repository.GetString("ASSUME_ROLE_ARN", ""); len(roleArn) > 0 {
+		options.AssumeRole = &AssumeRoleOptions{
+			RoleArn:     roleArn,
+			ExternalId:  repository.GetString("ASSUME_ROLE_EXTERNAL_ID", ""),
+			SessionName: repository.GetString("ASSUME_ROLE_SESSION_NAME", ""),
+		}
 	}
+	return options
 }
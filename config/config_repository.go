@@ -1,17 +1,256 @@
 package config
 
-import "os"
+import (
+	"errors"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 type ConfigRepositoryContract interface {
 	GetString(key string, defaultValue string) string
 }
 
+// ConfigView is a read-only view of the configuration, such as the one ConfigRepository.Snapshot returns.
+type ConfigView interface {
+	ConfigRepositoryContract
+	ValueLookup
+	TypedConfigContract
+	SecretConfigContract
+}
+
+// ReloadableConfigContract is a configuration whose values can change while the process runs.
+type ReloadableConfigContract interface {
+	// Snapshot returns a frozen view of the current configuration version for reads that must stay consistent.
+	Snapshot() ConfigView
+	// Subscribe calls callback whenever a reload changes the value of key. Call the returned func to unsubscribe.
+	Subscribe(key string, callback func(oldValue string, newValue string)) func()
+}
+
+// SnapshotOf returns a snapshot of repository when it implements ReloadableConfigContract, and
+// repository itself, through the AsValueLookup, AsTypedConfig and AsSecretConfig adapters, otherwise.
+func SnapshotOf(repository ConfigRepositoryContract) ConfigView {
+	if reloadable, ok := repository.(ReloadableConfigContract); ok {
+		return reloadable.Snapshot()
+	}
+	if view, ok := repository.(ConfigView); ok {
+		return view
+	}
+	return repositoryView{repository: repository}
+}
+
+// unsetValue is the default AsValueLookup passes to GetString to tell a missing key from an empty one.
+const unsetValue = "\x00unset"
+
+// repositoryView adds the optional config interfaces to a repository that only implements
+// ConfigRepositoryContract. It can't name the source of a value.
+type repositoryView struct {
+	repository ConfigRepositoryContract
+}
+
+// AsValueLookup returns repository itself when it implements ValueLookup, and an adapter over
+// GetString otherwise.
+func AsValueLookup(repository ConfigRepositoryContract) ValueLookup {
+	if lookup, ok := repository.(ValueLookup); ok {
+		return lookup
+	}
+	return repositoryView{repository: repository}
+}
+
+func (view repositoryView) GetString(key string, defaultValue string) string {
+	return view.repository.GetString(key, defaultValue)
+}
+
+func (view repositoryView) Lookup(key string) (string, string, bool) {
+	value := view.repository.GetString(key, unsetValue)
+	if value == unsetValue {
+		return "", "", false
+	}
+	return value, "", true
+}
+
+// ConfigRepository asks its sources in order and returns the first value found.
+// The zero value reads environment variables only.
 type ConfigRepository struct {
+	sources []ConfigSource
+	secrets *SecretResolver
+	logger  atomic.Pointer[slog.Logger]
+	current atomic.Pointer[ConfigSnapshot]
+
+	mutex          sync.Mutex
+	subscribers    map[string]map[int]func(string, string)
+	nextSubscriber int
+	stop           chan struct{}
+}
+
+func ProvideConfigRepository(sources ...ConfigSource) *ConfigRepository {
+	return &ConfigRepository{sources: sources}
+}
+
+// WithSecretResolver makes lookups resolve values such as secret://name#field and ssm://path.
+func (repo *ConfigRepository) WithSecretResolver(resolver *SecretResolver) *ConfigRepository {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	repo.secrets = resolver
+	repo.current.Store(repo.buildSnapshot(repo.live().Version + 1))
+	return repo
+}
+
+// WithLogger sets the logger that hears about failed reloads and secret lookups; nothing is logged
+// without one.
+func (repo *ConfigRepository) WithLogger(logger *slog.Logger) *ConfigRepository {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	repo.logger.Store(logger)
+	repo.current.Store(repo.buildSnapshot(repo.live().Version + 1))
+	return repo
 }
 
 func (repo *ConfigRepository) GetString(key string, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+	return repo.live().GetString(key, defaultValue)
+}
+
+func (repo *ConfigRepository) Lookup(key string) (string, string, bool) {
+	return repo.live().Lookup(key)
+}
+
+func (repo *ConfigRepository) Resolve(key string) (string, string, bool, error) {
+	return repo.live().Resolve(key)
+}
+
+func (repo *ConfigRepository) GetSecret(key string) (SecretValue, error) {
+	return repo.live().GetSecret(key)
+}
+
+// Snapshot returns a *ConfigSnapshot of the current version. It remembers the first answer for every
+// key, so later reads of the key return the same value even if a secret rotates or a remote value changes.
+func (repo *ConfigRepository) Snapshot() ConfigView {
+	return repo.live().freeze()
+}
+
+// live returns the current version. Unlike a frozen snapshot it reads secrets and remote sources on
+// every lookup, through their own caches.
+func (repo *ConfigRepository) live() *ConfigSnapshot {
+	if snapshot := repo.current.Load(); snapshot != nil {
+		return snapshot
+	}
+	repo.current.CompareAndSwap(nil, repo.buildSnapshot(0))
+	return repo.current.Load()
+}
+
+func (repo *ConfigRepository) Subscribe(key string, callback func(oldValue string, newValue string)) func() {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if repo.subscribers == nil {
+		repo.subscribers = map[string]map[int]func(string, string){}
+	}
+	if repo.subscribers[key] == nil {
+		repo.subscribers[key] = map[int]func(string, string){}
+	}
+	id := repo.nextSubscriber
+	repo.nextSubscriber++
+	repo.subscribers[key][id] = callback
+	return func() {
+		repo.mutex.Lock()
+		defer repo.mutex.Unlock()
+		delete(repo.subscribers[key], id)
+	}
+}
+
+// Reload asks every ReloadableSource for changes. When any source changed, a new snapshot is swapped
+// in and subscribers of keys whose value changed are called. The sources are read without holding the
+// repository's lock, so reads and subscriptions are never blocked by a slow source.
+func (repo *ConfigRepository) Reload() error {
+	changed := false
+	var problems []error
+	for _, source := range repo.sources {
+		reloadable, ok := source.(ReloadableSource)
+		if !ok {
+			continue
+		}
+		sourceChanged, err := reloadable.Reload()
+		if err != nil {
+			problems = append(problems, err)
+		}
+		changed = changed || sourceChanged
+	}
+	if !changed {
+		return errors.Join(problems...)
+	}
+	repo.mutex.Lock()
+	previous := repo.live()
+	next := repo.buildSnapshot(previous.Version + 1)
+	repo.current.Store(next)
+	subscribers := make(map[string][]func(string, string), len(repo.subscribers))
+	for key, callbacks := range repo.subscribers {
+		for _, callback := range callbacks {
+			subscribers[key] = append(subscribers[key], callback)
+		}
+	}
+	repo.mutex.Unlock()
+	for key, callbacks := range subscribers {
+		oldValue, _, _ := previous.Lookup(key)
+		newValue, _, _ := next.Lookup(key)
+		if oldValue == newValue {
+			continue
+		}
+		for _, callback := range callbacks {
+			callback(oldValue, newValue)
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// Watch reloads every interval until Close is called.
+func (repo *ConfigRepository) Watch(interval time.Duration) {
+	repo.mutex.Lock()
+	if repo.stop != nil {
+		repo.mutex.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	repo.stop = stop
+	repo.mutex.Unlock()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := repo.Reload(); err != nil {
+					utils.LoggerOrNop(repo.logger.Load()).Warn("Error in reloading config", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Close stops Watch.
+func (repo *ConfigRepository) Close() {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if repo.stop != nil {
+		close(repo.stop)
+		repo.stop = nil
+	}
+}
+
+func (repo *ConfigRepository) buildSnapshot(version uint64) *ConfigSnapshot {
+	sources := repo.sources
+	if len(sources) == 0 {
+		sources = []ConfigSource{EnvSource{}}
+	}
+	captured := make([]ConfigSource, len(sources))
+	for i, source := range sources {
+		if reloadable, ok := source.(ReloadableSource); ok {
+			captured[i] = reloadable.Snapshot()
+		} else {
+			captured[i] = source
+		}
 	}
-	return defaultValue
+	return &ConfigSnapshot{Version: version, sources: captured, secrets: repo.secrets, logger: repo.logger.Load()}
 }
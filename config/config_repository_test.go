@@ -0,0 +1,128 @@
+package config
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingSource is a ReloadableSource whose Reload waits for release.
+type blockingSource struct {
+	*MapSource
+	started chan struct{}
+	release chan struct{}
+}
+
+func (source *blockingSource) Reload() (bool, error) {
+	close(source.started)
+	<-source.release
+	return false, nil
+}
+
+func (source *blockingSource) Snapshot() ConfigSource {
+	return source.MapSource
+}
+
+func TestReloadNotifiesSubscribers(t *testing.T) {
+	path := writeFile(t, "settings.env", "TABLE_NAME=orders\n")
+	source, err := ProvideWatchedFileSource(path)
+	if err != nil {
+		t.Fatalf("ProvideWatchedFileSource: %v", err)
+	}
+	repository := ProvideConfigRepository(source)
+	before := repository.Snapshot()
+	var changes []string
+	repository.Subscribe("TABLE_NAME", func(oldValue string, newValue string) {
+		changes = append(changes, oldValue+"->"+newValue)
+	})
+
+	if err := os.WriteFile(path, []byte("TABLE_NAME=invoices\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	modTime := time.Now().Add(time.Second)
+	os.Chtimes(path, modTime, modTime)
+	if err := repository.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if len(changes) != 1 || changes[0] != "orders->invoices" {
+		t.Fatalf("subscriber saw %v", changes)
+	}
+	if value := before.GetString("TABLE_NAME", ""); value != "orders" {
+		t.Fatalf("old snapshot reads %q, want the value it was taken with", value)
+	}
+	if value := repository.GetString("TABLE_NAME", ""); value != "invoices" {
+		t.Fatalf("repository reads %q after Reload", value)
+	}
+}
+
+func TestSnapshotFreezesRemoteValues(t *testing.T) {
+	client := &countingClient{values: map[string]string{"LIMIT": "10"}, calls: map[string]int{}}
+	repository := ProvideConfigRepository(ProvidePollingRemoteSource("ssm", client))
+	snapshot := repository.Snapshot()
+	if value := snapshot.GetString("LIMIT", ""); value != "10" {
+		t.Fatalf("LIMIT = %q", value)
+	}
+	client.values["LIMIT"] = "20"
+	client.values["BURST"] = "5"
+	if err := repository.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if value := snapshot.GetString("LIMIT", ""); value != "10" {
+		t.Fatalf("snapshot LIMIT = %q after Reload, want 10", value)
+	}
+	if value := repository.GetString("LIMIT", ""); value != "20" {
+		t.Fatalf("repository LIMIT = %q after Reload, want 20", value)
+	}
+	first := snapshot.GetString("BURST", "")
+	client.values["BURST"] = "6"
+	if second := snapshot.GetString("BURST", ""); second != first {
+		t.Fatalf("snapshot BURST changed from %q to %q", first, second)
+	}
+}
+
+func TestSnapshotFreezesSecrets(t *testing.T) {
+	client := &countingClient{values: map[string]string{"db": `{"password": "first"}`}, calls: map[string]int{}}
+	repository := secretRepository(client)
+	snapshot := repository.Snapshot()
+	if secret, err := snapshot.GetSecret("DB_PASSWORD"); err != nil || secret.Reveal() != "first" {
+		t.Fatalf("GetSecret = %q, %v", secret.Reveal(), err)
+	}
+	client.values["db"] = `{"password": "rotated"}`
+	time.Sleep(time.Millisecond)
+	if secret, _ := snapshot.GetSecret("DB_PASSWORD"); secret.Reveal() != "first" {
+		t.Fatalf("snapshot secret = %q after rotation, want the first value", secret.Reveal())
+	}
+	if secret, _ := repository.GetSecret("DB_PASSWORD"); secret.Reveal() != "rotated" {
+		t.Fatalf("repository secret = %q after rotation, want the rotated value", secret.Reveal())
+	}
+}
+
+func TestReloadDoesNotBlockReadersDuringSourceIO(t *testing.T) {
+	source := &blockingSource{
+		MapSource: ProvideMapSource("slow", map[string]string{"TABLE_NAME": "orders"}),
+		started:   make(chan struct{}),
+		release:   make(chan struct{}),
+	}
+	repository := ProvideConfigRepository(source)
+	var reloading sync.WaitGroup
+	reloading.Add(1)
+	go func() {
+		defer reloading.Done()
+		repository.Reload()
+	}()
+	<-source.started
+	done := make(chan struct{})
+	go func() {
+		repository.Subscribe("TABLE_NAME", func(string, string) {})()
+		repository.Snapshot().GetString("TABLE_NAME", "")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Subscribe and Snapshot waited for a source to reload")
+	}
+	close(source.release)
+	reloading.Wait()
+}
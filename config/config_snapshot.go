@@ -0,0 +1,134 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"log/slog"
+	"sync"
+)
+
+// ValueLookup is anything that can answer a config lookup, such as a repository or one of its snapshots.
+type ValueLookup interface {
+	Lookup(key string) (value string, source string, found bool)
+}
+
+// ConfigSnapshot is one immutable version of the configuration. Hold on to a snapshot for the length
+// of a request so every read sees the same version even if the repository reloads meanwhile.
+type ConfigSnapshot struct {
+	Version uint64
+	sources []ConfigSource
+	secrets *SecretResolver
+	logger  *slog.Logger
+	// frozen remembers the first successful answer for every key; it is nil for the repository's
+	// live version.
+	frozen *frozenValues
+}
+
+type frozenValues struct {
+	mutex  sync.Mutex
+	values map[string]resolvedValue
+}
+
+type resolvedValue struct {
+	value  string
+	source string
+	found  bool
+}
+
+func (snapshot *ConfigSnapshot) freeze() *ConfigSnapshot {
+	return &ConfigSnapshot{
+		Version: snapshot.Version,
+		sources: snapshot.sources,
+		secrets: snapshot.secrets,
+		logger:  snapshot.logger,
+		frozen:  &frozenValues{values: map[string]resolvedValue{}},
+	}
+}
+
+func (snapshot *ConfigSnapshot) GetString(key string, defaultValue string) string {
+	if value, _, found := snapshot.Lookup(key); found {
+		return value
+	}
+	return defaultValue
+}
+
+// Lookup can't return the error of a secret reference that fails to resolve, so it logs it and reports
+// the key as missing, and GetString falls back to its default. Resolve, GetSecret, Bind and the typed
+// getters return the error instead.
+func (snapshot *ConfigSnapshot) Lookup(key string) (string, string, bool) {
+	value, source, found, err := snapshot.Resolve(key)
+	if err != nil {
+		utils.LoggerOrNop(snapshot.logger).Warn("Error in resolving secret", "key", key, "error", err)
+		return "", "", false
+	}
+	return value, source, found
+}
+
+// Resolve is Lookup that returns the error of a secret reference that could not be resolved, together
+// with the name of the source that held the reference. Errors are not remembered by a frozen snapshot.
+func (snapshot *ConfigSnapshot) Resolve(key string) (string, string, bool, error) {
+	if snapshot.frozen == nil {
+		return snapshot.resolve(key)
+	}
+	snapshot.frozen.mutex.Lock()
+	remembered, ok := snapshot.frozen.values[key]
+	snapshot.frozen.mutex.Unlock()
+	if ok {
+		return remembered.value, remembered.source, remembered.found, nil
+	}
+	value, source, found, err := snapshot.resolve(key)
+	if err != nil {
+		return value, source, found, err
+	}
+	snapshot.frozen.mutex.Lock()
+	defer snapshot.frozen.mutex.Unlock()
+	// A concurrent first read may have won; every reader gets the answer that was remembered.
+	if remembered, ok := snapshot.frozen.values[key]; ok {
+		return remembered.value, remembered.source, remembered.found, nil
+	}
+	snapshot.frozen.values[key] = resolvedValue{value: value, source: source, found: found}
+	return value, source, found, nil
+}
+
+func (snapshot *ConfigSnapshot) resolve(key string) (string, string, bool, error) {
+	for _, source := range snapshot.sources {
+		if value, found := source.Lookup(key); found {
+			if snapshot.secrets != nil && snapshot.secrets.IsSecretReference(value) {
+				secret, err := snapshot.secrets.Resolve(context.TODO(), value)
+				if err != nil {
+					return "", source.Name(), true, fmt.Errorf("config key %s: %w", key, err)
+				}
+				return secret.Reveal(), source.Name(), true, nil
+			}
+			return value, source.Name(), true, nil
+		}
+	}
+	return "", "", false, nil
+}
+
+// LookupRaw returns the value of key as its source holds it, without resolving secret references, and
+// whether that value is a secret reference.
+func (snapshot *ConfigSnapshot) LookupRaw(key string) (value string, source string, secretReference bool, found bool) {
+	for _, source := range snapshot.sources {
+		if value, found := source.Lookup(key); found {
+			return value, source.Name(), snapshot.secrets != nil && snapshot.secrets.IsSecretReference(value), true
+		}
+	}
+	return "", "", false, false
+}
+
+func (snapshot *ConfigSnapshot) GetSecret(key string) (SecretValue, error) {
+	value, _, found, err := snapshot.Resolve(key)
+	if err != nil {
+		return SecretValue{}, err
+	}
+	if !found {
+		return SecretValue{}, fmt.Errorf("config key %s is not set", key)
+	}
+	return NewSecretValue(value), nil
+}
+
+func (snapshot *ConfigSnapshot) Bind(target any) error {
+	return Bind(snapshot, target)
+}
@@ -0,0 +1,40 @@
+package config
+
+import "os"
+
+// ConfigSource is one layer of configuration, such as the environment or a file.
+type ConfigSource interface {
+	Name() string
+	Lookup(key string) (string, bool)
+}
+
+// EnvSource reads environment variables.
+type EnvSource struct {
+}
+
+func (source EnvSource) Name() string {
+	return "env"
+}
+
+func (source EnvSource) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+// MapSource serves values from memory, e.g. defaults assembled in code or values parsed from a file.
+type MapSource struct {
+	name   string
+	values map[string]string
+}
+
+func ProvideMapSource(name string, values map[string]string) *MapSource {
+	return &MapSource{name: name, values: values}
+}
+
+func (source *MapSource) Name() string {
+	return source.name
+}
+
+func (source *MapSource) Lookup(key string) (string, bool) {
+	value, ok := source.values[key]
+	return value, ok
+}
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/endpointcreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"os"
+	"time"
+)
+
+type CredentialSource int
+
+const (
+	// CredentialSourceDefault walks the standard SDK chain: environment variables, shared config and
+	// credentials files, web identity, container credentials and finally the EC2 instance metadata service.
+	CredentialSourceDefault CredentialSource = iota
+	// CredentialSourceStatic uses StaticAccessKeyId, StaticSecretKey and StaticSessionToken.
+	CredentialSourceStatic
+	// CredentialSourceEnvironment only reads the AWS_ACCESS_KEY_ID family of environment variables.
+	CredentialSourceEnvironment
+	// CredentialSourceSharedProfile reads the credentials of Profile from the shared config and credentials
+	// files, including profiles that assume a role, use SSO or run a credential_process. Credentials in
+	// the environment are ignored.
+	CredentialSourceSharedProfile
+	// CredentialSourceWebIdentity exchanges the token in WebIdentityTokenFile for WebIdentityRoleArn.
+	CredentialSourceWebIdentity
+	// CredentialSourceContainer reads the ECS/EKS container credentials endpoint.
+	CredentialSourceContainer
+	// CredentialSourceIMDS reads the EC2 instance metadata service.
+	CredentialSourceIMDS
+)
+
+const (
+	defaultRegion            = "us-east-2"
+	ecsContainerHost         = "http://169.254.170.2"
+	defaultAssumeRoleSession = "awsgorocket"
+)
+
+// AssumeRoleOptions wraps whichever credentials were resolved in an STS AssumeRole call.
+type AssumeRoleOptions struct {
+	RoleArn           string
+	ExternalId        string
+	SessionName       string
+	Duration          time.Duration
+	Tags              map[string]string
+	TransitiveTagKeys []string
+}
+
+type ConfigProviderOptions struct {
+	Region string
+	Source CredentialSource
+
+	StaticAccessKeyId  string
+	StaticSecretKey    string
+	StaticSessionToken string
+
+	Profile                string
+	SharedConfigFiles      []string
+	SharedCredentialsFiles []string
+
+	WebIdentityTokenFile string
+	WebIdentityRoleArn   string
+
+	// ContainerEndpoint and ContainerAuthorizationToken default to the AWS_CONTAINER_* environment variables.
+	ContainerEndpoint           string
+	ContainerAuthorizationToken string
+
+	DisableIMDS bool
+	AssumeRole  *AssumeRoleOptions
+
+	// Environment names an EnvironmentProfile such as "local" whose region, endpoints and credentials
+	// apply wherever the fields above are left empty.
+	Environment string
+	// Endpoints overrides the endpoint per service key, e.g. ServiceDynamoDb: "http://localhost:8000".
+	Endpoints map[string]string
+}
+
+// LoadSdkConfig resolves region and credentials for the chosen source, then applies AssumeRole on top.
+func LoadSdkConfig(ctx context.Context, options ConfigProviderOptions) (aws.Config, error) {
+	sdkConfig, _, err := loadSdkConfig(ctx, options)
+	return sdkConfig, err
+}
+
+func loadSdkConfig(ctx context.Context, options ConfigProviderOptions) (aws.Config, EnvironmentProfile, error) {
+	options, environment, err := resolveEnvironment(options)
+	if err != nil {
+		return aws.Config{}, environment, err
+	}
+	var loadOptions []func(*awsconfig.LoadOptions) error
+	if len(options.Region) > 0 {
+		loadOptions = append(loadOptions, awsconfig.WithRegion(options.Region))
+	}
+	if len(options.Profile) > 0 {
+		loadOptions = append(loadOptions, awsconfig.WithSharedConfigProfile(options.Profile))
+	}
+	if len(options.SharedConfigFiles) > 0 {
+		loadOptions = append(loadOptions, awsconfig.WithSharedConfigFiles(options.SharedConfigFiles))
+	}
+	if len(options.SharedCredentialsFiles) > 0 {
+		loadOptions = append(loadOptions, awsconfig.WithSharedCredentialsFiles(options.SharedCredentialsFiles))
+	}
+	if options.DisableIMDS {
+		loadOptions = append(loadOptions, awsconfig.WithEC2IMDSClientEnableState(imds.ClientDisabled))
+	}
+	sourceProvider, err := credentialSourceProvider(ctx, options)
+	if err != nil {
+		return aws.Config{}, environment, err
+	}
+	if sourceProvider != nil {
+		loadOptions = append(loadOptions, awsconfig.WithCredentialsProvider(sourceProvider))
+	}
+	sdkConfig, err := awsconfig.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return aws.Config{}, environment, err
+	}
+	if len(sdkConfig.Region) == 0 {
+		sdkConfig.Region = defaultRegion
+	}
+	environment.Region = sdkConfig.Region
+	if options.Source == CredentialSourceWebIdentity {
+		if len(options.WebIdentityTokenFile) == 0 || len(options.WebIdentityRoleArn) == 0 {
+			return aws.Config{}, environment, errors.New("web identity needs a token file and a role arn")
+		}
+		sdkConfig.Credentials = aws.NewCredentialsCache(stscreds.NewWebIdentityRoleProvider(
+			stsClient(sdkConfig, environment),
+			options.WebIdentityRoleArn,
+			stscreds.IdentityTokenFile(options.WebIdentityTokenFile),
+		))
+	}
+	if options.AssumeRole != nil {
+		sdkConfig.Credentials = aws.NewCredentialsCache(assumeRoleProvider(stsClient(sdkConfig, environment), *options.AssumeRole))
+	}
+	return sdkConfig, environment, nil
+}
+
+// credentialSourceProvider returns nil when the SDK should resolve credentials itself.
+func credentialSourceProvider(ctx context.Context, options ConfigProviderOptions) (aws.CredentialsProvider, error) {
+	switch options.Source {
+	case CredentialSourceDefault, CredentialSourceWebIdentity:
+		return nil, nil
+	case CredentialSourceStatic:
+		if len(options.StaticAccessKeyId) == 0 || len(options.StaticSecretKey) == 0 {
+			return nil, errors.New("static credentials need an access key id and a secret key")
+		}
+		return credentials.NewStaticCredentialsProvider(
+			options.StaticAccessKeyId,
+			options.StaticSecretKey,
+			options.StaticSessionToken,
+		), nil
+	case CredentialSourceEnvironment:
+		envConfig, err := awsconfig.NewEnvConfig()
+		if err != nil {
+			return nil, err
+		}
+		if !envConfig.Credentials.HasKeys() {
+			return nil, errors.New("no credentials found in the environment")
+		}
+		return credentials.StaticCredentialsProvider{Value: envConfig.Credentials}, nil
+	case CredentialSourceSharedProfile:
+		return sharedProfileProvider(ctx, options)
+	case CredentialSourceContainer:
+		endpoint, token := containerEndpoint(options)
+		if len(endpoint) == 0 {
+			return nil, errors.New("no container credentials endpoint configured")
+		}
+		return aws.NewCredentialsCache(endpointcreds.New(endpoint, func(endpointOptions *endpointcreds.Options) {
+			endpointOptions.AuthorizationToken = token
+		})), nil
+	case CredentialSourceIMDS:
+		if options.DisableIMDS {
+			return nil, errors.New("IMDS credentials were requested but IMDS is disabled")
+		}
+		return aws.NewCredentialsCache(ec2rolecreds.New()), nil
+	default:
+		return nil, fmt.Errorf("unknown credential source: %d", options.Source)
+	}
+}
+
+// sharedProfileProvider loads the credentials of options.Profile alone. Naming the profile makes the SDK
+// resolve it ahead of the environment variables it would otherwise check first.
+func sharedProfileProvider(ctx context.Context, options ConfigProviderOptions) (aws.CredentialsProvider, error) {
+	if len(options.Profile) == 0 {
+		return nil, errors.New("shared profile credentials need a profile")
+	}
+	loadOptions := []func(*awsconfig.LoadOptions) error{awsconfig.WithSharedConfigProfile(options.Profile)}
+	if len(options.Region) > 0 {
+		loadOptions = append(loadOptions, awsconfig.WithRegion(options.Region))
+	}
+	if len(options.SharedConfigFiles) > 0 {
+		loadOptions = append(loadOptions, awsconfig.WithSharedConfigFiles(options.SharedConfigFiles))
+	}
+	if len(options.SharedCredentialsFiles) > 0 {
+		loadOptions = append(loadOptions, awsconfig.WithSharedCredentialsFiles(options.SharedCredentialsFiles))
+	}
+	profileConfig, err := awsconfig.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("could not load profile %s: %w", options.Profile, err)
+	}
+	if profileConfig.Credentials == nil {
+		return nil, fmt.Errorf("profile %s has no credentials", options.Profile)
+	}
+	return profileConfig.Credentials, nil
+}
+
+func containerEndpoint(options ConfigProviderOptions) (string, string) {
+	endpoint := options.ContainerEndpoint
+	if len(endpoint) == 0 {
+		endpoint = os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	}
+	if relativePath := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); len(endpoint) == 0 && len(relativePath) > 0 {
+		endpoint = ecsContainerHost + relativePath
+	}
+	token := options.ContainerAuthorizationToken
+	if len(token) == 0 {
+		token = os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	}
+	return endpoint, token
+}
+
+func stsClient(sdkConfig aws.Config, environment EnvironmentProfile) *sts.Client {
+	return sts.NewFromConfig(sdkConfig, func(stsOptions *sts.Options) {
+		stsOptions.BaseEndpoint = environment.BaseEndpoint(ServiceSts)
+	})
+}
+
+func assumeRoleProvider(client *sts.Client, options AssumeRoleOptions) aws.CredentialsProvider {
+	return stscreds.NewAssumeRoleProvider(client, options.RoleArn, func(roleOptions *stscreds.AssumeRoleOptions) {
+		roleOptions.RoleSessionName = defaultAssumeRoleSession
+		if len(options.SessionName) > 0 {
+			roleOptions.RoleSessionName = options.SessionName
+		}
+		if len(options.ExternalId) > 0 {
+			roleOptions.ExternalID = aws.String(options.ExternalId)
+		}
+		if options.Duration > 0 {
+			roleOptions.Duration = options.Duration
+		}
+		for key, value := range options.Tags {
+			roleOptions.Tags = append(roleOptions.Tags, ststypes.Tag{Key: aws.String(key), Value: aws.String(value)})
+		}
+		roleOptions.TransitiveTagKeys = options.TransitiveTagKeys
+	})
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestSharedProfileIgnoresEnvironmentCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "environment-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "environment-secret")
+	credentialsFile := writeFile(t, "credentials", "[reporting]\naws_access_key_id = profile-key\naws_secret_access_key = profile-secret\n")
+	configFile := writeFile(t, "config", "")
+
+	sdkConfig, err := LoadSdkConfig(context.Background(), ConfigProviderOptions{
+		Region:                 "eu-west-1",
+		Source:                 CredentialSourceSharedProfile,
+		Profile:                "reporting",
+		SharedConfigFiles:      []string{configFile},
+		SharedCredentialsFiles: []string{credentialsFile},
+	})
+	if err != nil {
+		t.Fatalf("LoadSdkConfig: %v", err)
+	}
+	credentials, err := sdkConfig.Credentials.Retrieve(context.Background())
+	if err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if credentials.AccessKeyID != "profile-key" {
+		t.Fatalf("access key = %q, want the profile's key", credentials.AccessKeyID)
+	}
+}
+
+func TestSharedProfileFailsForUnknownProfile(t *testing.T) {
+	_, err := LoadSdkConfig(context.Background(), ConfigProviderOptions{
+		Region:                 "eu-west-1",
+		Source:                 CredentialSourceSharedProfile,
+		Profile:                "missing",
+		SharedConfigFiles:      []string{writeFile(t, "config", "")},
+		SharedCredentialsFiles: []string{writeFile(t, "credentials", "")},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a profile that does not exist")
+	}
+}
+
+func TestProvideConfigProviderSurfacesLoadErrors(t *testing.T) {
+	t.Setenv("ENVIRONMENT_PROFILE", "no-such-profile")
+	provider := ProvideConfigProvider()
+	if provider.SdkConfig.Credentials == nil {
+		t.Fatal("expected credentials that report the load error")
+	}
+	if _, err := provider.SdkConfig.Credentials.Retrieve(context.Background()); err == nil {
+		t.Fatal("expected Retrieve to fail with the load error")
+	}
+}
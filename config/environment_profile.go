@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"sync"
+)
+
+const (
+	EnvironmentLocal   = "local"
+	EnvironmentStaging = "staging"
+	EnvironmentProd    = "prod"
+)
+
+// Service keys for EnvironmentProfile.Endpoints: the SDK service ID in lower case without spaces.
+const (
+	ServiceDynamoDb       = "dynamodb"
+	ServiceCloudWatch     = "cloudwatch"
+	ServiceCloudWatchLogs = "cloudwatchlogs"
+	ServiceS3             = "s3"
+	ServiceKms            = "kms"
+	ServiceSsm            = "ssm"
+	ServiceSecretsManager = "secretsmanager"
+	ServiceSts            = "sts"
+)
+
+var endpointServices = []string{
+	ServiceDynamoDb, ServiceCloudWatch, ServiceCloudWatchLogs, ServiceS3, ServiceKms, ServiceSsm, ServiceSecretsManager, ServiceSts,
+}
+
+// EnvironmentProfile overrides region, endpoints and credentials for a deployment environment,
+// e.g. pointing DynamoDB at DynamoDB Local and everything else at LocalStack.
+type EnvironmentProfile struct {
+	Name      string
+	Region    string
+	Endpoints map[string]string
+	// StaticAccessKeyId and StaticSecretKey, when set, replace the credential chain.
+	StaticAccessKeyId string
+	StaticSecretKey   string
+}
+
+func (profile EnvironmentProfile) HasEndpoint(service string) bool {
+	_, ok := profile.Endpoints[service]
+	return ok
+}
+
+var (
+	environmentProfilesMutex sync.RWMutex
+	environmentProfiles      = map[string]EnvironmentProfile{
+		EnvironmentLocal: {
+			Name:   EnvironmentLocal,
+			Region: "us-east-1",
+			Endpoints: map[string]string{
+				ServiceDynamoDb:       "http://localhost:8000",
+				ServiceCloudWatch:     "http://localhost:4566",
+				ServiceCloudWatchLogs: "http://localhost:4566",
+				ServiceS3:             "http://localhost:4566",
+				ServiceKms:            "http://localhost:4566",
+				ServiceSsm:            "http://localhost:4566",
+				ServiceSecretsManager: "http://localhost:4566",
+				ServiceSts:            "http://localhost:4566",
+			},
+			StaticAccessKeyId: "local",
+			StaticSecretKey:   "local",
+		},
+		EnvironmentStaging: {Name: EnvironmentStaging},
+		EnvironmentProd:    {Name: EnvironmentProd},
+	}
+)
+
+// RegisterEnvironmentProfile adds a profile or replaces a built-in one.
+func RegisterEnvironmentProfile(profile EnvironmentProfile) {
+	environmentProfilesMutex.Lock()
+	defer environmentProfilesMutex.Unlock()
+	environmentProfiles[profile.Name] = profile
+}
+
+func LookupEnvironmentProfile(name string) (EnvironmentProfile, bool) {
+	environmentProfilesMutex.RLock()
+	defer environmentProfilesMutex.RUnlock()
+	profile, ok := environmentProfiles[name]
+	return profile, ok
+}
+
+// resolveEnvironment merges the named profile with the explicit options, which always win.
+func resolveEnvironment(options ConfigProviderOptions) (ConfigProviderOptions, EnvironmentProfile, error) {
+	resolved := EnvironmentProfile{Name: options.Environment, Endpoints: map[string]string{}}
+	if len(options.Environment) > 0 {
+		profile, ok := LookupEnvironmentProfile(options.Environment)
+		if !ok {
+			return options, resolved, fmt.Errorf("unknown environment profile: %s", options.Environment)
+		}
+		resolved.Region = profile.Region
+		for service, endpoint := range profile.Endpoints {
+			resolved.Endpoints[service] = endpoint
+		}
+		if len(options.Region) == 0 {
+			options.Region = profile.Region
+		}
+		if options.Source == CredentialSourceDefault && len(profile.StaticAccessKeyId) > 0 {
+			options.Source = CredentialSourceStatic
+			options.StaticAccessKeyId = profile.StaticAccessKeyId
+			options.StaticSecretKey = profile.StaticSecretKey
+		}
+	}
+	for service, endpoint := range options.Endpoints {
+		resolved.Endpoints[service] = endpoint
+	}
+	if len(options.Region) > 0 {
+		resolved.Region = options.Region
+	}
+	return options, resolved, nil
+}
+
+// BaseEndpoint returns the override for service, for the BaseEndpoint option of its SDK client,
+// or nil to leave the endpoint to the SDK, e.g.
+//
+//	dynamodb.NewFromConfig(provider.SdkConfig, func(options *dynamodb.Options) {
+//		options.BaseEndpoint = provider.Environment.BaseEndpoint(config.ServiceDynamoDb)
+//	})
+func (profile EnvironmentProfile) BaseEndpoint(service string) *string {
+	endpoint, ok := profile.Endpoints[service]
+	if !ok {
+		return nil
+	}
+	return aws.String(endpoint)
+}
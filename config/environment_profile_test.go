@@ -0,0 +1,47 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestEnvironmentProfileBaseEndpoints(t *testing.T) {
+	provider, err := ProvideConfigProviderWithOptions(ConfigProviderOptions{
+		Environment: EnvironmentLocal,
+		Endpoints:   map[string]string{ServiceS3: "http://localhost:9000"},
+		DisableIMDS: true,
+	})
+	if err != nil {
+		t.Fatalf("ProvideConfigProviderWithOptions: %v", err)
+	}
+	if provider.SdkConfig.EndpointResolverWithOptions != nil {
+		t.Fatal("expected no global endpoint resolver on the SDK config")
+	}
+	tests := map[string]string{
+		ServiceDynamoDb: "http://localhost:8000",
+		ServiceS3:       "http://localhost:9000",
+		ServiceSsm:      "http://localhost:4566",
+	}
+	for service, want := range tests {
+		endpoint := provider.Environment.BaseEndpoint(service)
+		if endpoint == nil || *endpoint != want {
+			t.Errorf("BaseEndpoint(%s) = %v, want %s", service, endpoint, want)
+		}
+	}
+}
+
+func TestEnvironmentProfileLeavesOtherServicesToTheSdk(t *testing.T) {
+	provider, err := ProvideConfigProviderWithOptions(ConfigProviderOptions{
+		Region:      "eu-west-1",
+		Endpoints:   map[string]string{ServiceDynamoDb: "http://localhost:8000"},
+		DisableIMDS: true,
+	})
+	if err != nil {
+		t.Fatalf("ProvideConfigProviderWithOptions: %v", err)
+	}
+	if endpoint := provider.Environment.BaseEndpoint(ServiceS3); endpoint != nil {
+		t.Fatalf("BaseEndpoint(s3) = %s, want nil", *endpoint)
+	}
+	if provider.SdkConfig.Region != "eu-west-1" {
+		t.Fatalf("region = %s, want eu-west-1", provider.SdkConfig.Region)
+	}
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ProvideDotEnvSource reads KEY=VALUE lines from a .env file. Blank lines, # comments and a leading
+// "export " are ignored, and single or double quotes around values are removed.
+func ProvideDotEnvSource(path string) (*MapSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parseDotEnv(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	return ProvideMapSource("dotenv:"+path, values), nil
+}
+
+// ProvideFileSource reads a YAML, JSON or TOML file, picked by extension. Nested keys are joined
+// with dots ("database.table") and lists of scalars are joined with commas. Lists of maps are kept
+// as JSON, and each of their members is flattened under its index ("servers.0.host").
+func ProvideFileSource(path string) (*MapSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parseStructuredFile(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	return ProvideMapSource("file:"+path, values), nil
+}
+
+func parseStructuredFile(path string, data []byte) (map[string]string, error) {
+	var document map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return nil, err
+		}
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&document); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, &document); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config file type: %s", filepath.Ext(path))
+	}
+	values := map[string]string{}
+	flatten("", document, values)
+	return values, nil
+}
+
+func flatten(prefix string, value any, values map[string]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			name := key
+			if len(prefix) > 0 {
+				name = prefix + "." + key
+			}
+			flatten(name, v[key], values)
+		}
+	case []map[string]any:
+		members := make([]any, 0, len(v))
+		for _, member := range v {
+			members = append(members, member)
+		}
+		flatten(prefix, members, values)
+	case []any:
+		if !scalarList(v) {
+			// Lists of maps or lists keep their JSON under the list's key, and every member is also
+			// flattened under its index, e.g. "servers.0.host".
+			if encoded, err := json.Marshal(v); err == nil {
+				values[prefix] = string(encoded)
+			}
+			for i, member := range v {
+				flatten(prefix+"."+strconv.Itoa(i), member, values)
+			}
+			return
+		}
+		members := make([]string, 0, len(v))
+		for _, member := range v {
+			members = append(members, scalarString(member))
+		}
+		values[prefix] = strings.Join(members, ",")
+	default:
+		values[prefix] = scalarString(v)
+	}
+}
+
+func scalarList(list []any) bool {
+	for _, member := range list {
+		switch member.(type) {
+		case map[string]any, []any, []map[string]any:
+			return false
+		}
+	}
+	return true
+}
+
+func scalarString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func parseDotEnv(data []byte) (map[string]string, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d is not KEY=VALUE", lineNumber)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			if value[0] == '"' {
+				if unquoted, err := strconv.Unquote(value); err == nil {
+					value = unquoted
+				} else {
+					value = value[1 : len(value)-1]
+				}
+			} else {
+				value = value[1 : len(value)-1]
+			}
+		} else if comment := strings.Index(value, " #"); comment >= 0 {
+			value = strings.TrimSpace(value[:comment])
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
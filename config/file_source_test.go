@@ -0,0 +1,30 @@
+package config
+
+import "testing"
+
+func TestFileSourceFlattensListsOfMaps(t *testing.T) {
+	tests := map[string]string{
+		"settings.yaml": "servers:\n  - host: a.example.com\n    port: 80\n  - host: b.example.com\n    port: 81\ntags: [blue, green]\n",
+		"settings.json": `{"servers": [{"host": "a.example.com", "port": 80}, {"host": "b.example.com", "port": 81}], "tags": ["blue", "green"]}`,
+		"settings.toml": "tags = [\"blue\", \"green\"]\n[[servers]]\nhost = \"a.example.com\"\nport = 80\n[[servers]]\nhost = \"b.example.com\"\nport = 81\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			source, err := ProvideFileSource(writeFile(t, name, content))
+			if err != nil {
+				t.Fatalf("ProvideFileSource: %v", err)
+			}
+			want := map[string]string{
+				"servers":        `[{"host":"a.example.com","port":80},{"host":"b.example.com","port":81}]`,
+				"servers.0.host": "a.example.com",
+				"servers.1.port": "81",
+				"tags":           "blue,green",
+			}
+			for key, value := range want {
+				if got, _ := source.Lookup(key); got != value {
+					t.Errorf("%s = %q, want %q", key, got, value)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ProvideFlagSource reads --KEY=value and --KEY value pairs from command-line arguments, usually
+// os.Args[1:]. A flag with no value is read as "true". Negative numbers such as -1 are values, not
+// flags. Anything after a bare "--" is ignored.
+func ProvideFlagSource(args []string) *MapSource {
+	values := map[string]string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if !isFlag(arg) {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		if key, value, found := strings.Cut(name, "="); found {
+			values[key] = value
+			continue
+		}
+		if i+1 < len(args) && !isFlag(args[i+1]) {
+			values[name] = args[i+1]
+			i++
+			continue
+		}
+		values[name] = "true"
+	}
+	return ProvideMapSource("flags", values)
+}
+
+func isFlag(arg string) bool {
+	if !strings.HasPrefix(arg, "-") || arg == "-" {
+		return false
+	}
+	_, err := strconv.ParseFloat(arg, 64)
+	return err != nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import "testing"
+
+func TestFlagSource(t *testing.T) {
+	source := ProvideFlagSource([]string{
+		"--RETRIES", "-1",
+		"--OFFSET=-2.5",
+		"--VERBOSE",
+		"--THRESHOLD", "-0.25",
+		"-DRY_RUN",
+		"--NAME", "orders",
+		"--", "--IGNORED", "value",
+	})
+	want := map[string]string{
+		"RETRIES":   "-1",
+		"OFFSET":    "-2.5",
+		"VERBOSE":   "true",
+		"THRESHOLD": "-0.25",
+		"DRY_RUN":   "true",
+		"NAME":      "orders",
+	}
+	for key, value := range want {
+		if got, found := source.Lookup(key); !found || got != value {
+			t.Errorf("%s = %q (found %v), want %q", key, got, found, value)
+		}
+	}
+	for _, key := range []string{"1", "0.25", "IGNORED"} {
+		if _, found := source.Lookup(key); found {
+			t.Errorf("%s should not be a flag", key)
+		}
+	}
+}
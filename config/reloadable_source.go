@@ -0,0 +1,206 @@
+package config
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ReloadableSource is a ConfigSource whose values can change while the process runs.
+type ReloadableSource interface {
+	ConfigSource
+	// Reload picks up new values and reports whether anything changed.
+	Reload() (bool, error)
+	// Snapshot returns a source frozen at the current values.
+	Snapshot() ConfigSource
+}
+
+// WatchedFileSource is a .env, YAML, JSON or TOML file that is re-read when its contents change.
+type WatchedFileSource struct {
+	path   string
+	name   string
+	parse  func([]byte) (map[string]string, error)
+	values atomic.Pointer[map[string]string]
+
+	mutex   sync.Mutex
+	modTime time.Time
+	size    int64
+	digest  [sha256.Size]byte
+}
+
+func ProvideWatchedFileSource(path string) (*WatchedFileSource, error) {
+	source := &WatchedFileSource{path: path}
+	if filepath.Base(path) == ".env" || strings.EqualFold(filepath.Ext(path), ".env") {
+		source.name = "dotenv:" + path
+		source.parse = parseDotEnv
+	} else {
+		source.name = "file:" + path
+		source.parse = func(data []byte) (map[string]string, error) {
+			return parseStructuredFile(path, data)
+		}
+	}
+	if _, err := source.Reload(); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+func (source *WatchedFileSource) Name() string {
+	return source.name
+}
+
+func (source *WatchedFileSource) Lookup(key string) (string, bool) {
+	value, ok := (*source.values.Load())[key]
+	return value, ok
+}
+
+func (source *WatchedFileSource) Snapshot() ConfigSource {
+	return ProvideMapSource(source.name, *source.values.Load())
+}
+
+// Reload re-reads the file when its size or modification time moved, and only swaps values when the
+// contents differ. A file that fails to parse keeps the previous values.
+func (source *WatchedFileSource) Reload() (bool, error) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+	info, err := os.Stat(source.path)
+	if err != nil {
+		return false, err
+	}
+	if source.values.Load() != nil && info.ModTime().Equal(source.modTime) && info.Size() == source.size {
+		return false, nil
+	}
+	data, err := os.ReadFile(source.path)
+	if err != nil {
+		return false, err
+	}
+	source.modTime, source.size = info.ModTime(), info.Size()
+	digest := sha256.Sum256(data)
+	if source.values.Load() != nil && digest == source.digest {
+		return false, nil
+	}
+	values, err := source.parse(data)
+	if err != nil {
+		return false, fmt.Errorf("could not parse %s: %w", source.path, err)
+	}
+	source.digest = digest
+	source.values.Store(&values)
+	return true, nil
+}
+
+type remoteValue struct {
+	value string
+	found bool
+}
+
+// PollingRemoteSource remembers every key it was asked for and fetches them again on Reload,
+// so changes in a remote store such as Parameter Store are picked up. Like RemoteSource, it treats a
+// failed lookup as a miss and fetches the key again once maxErrorTtl has passed.
+type PollingRemoteSource struct {
+	name   string
+	client RemoteValueClient
+	mutex  sync.Mutex
+	values atomic.Pointer[map[string]remoteValue]
+	// failures holds when each failed key may be fetched again; it is guarded by mutex.
+	failures map[string]time.Time
+	logger   *slog.Logger
+}
+
+func ProvidePollingRemoteSource(name string, client RemoteValueClient) *PollingRemoteSource {
+	source := &PollingRemoteSource{name: name, client: client, failures: map[string]time.Time{}}
+	source.values.Store(&map[string]remoteValue{})
+	return source
+}
+
+// WithLogger sets the logger that hears about failed lookups; nothing is logged without one.
+func (source *PollingRemoteSource) WithLogger(logger *slog.Logger) *PollingRemoteSource {
+	source.logger = logger
+	return source
+}
+
+func (source *PollingRemoteSource) Name() string {
+	return source.name
+}
+
+func (source *PollingRemoteSource) Lookup(key string) (string, bool) {
+	if cached, ok := (*source.values.Load())[key]; ok {
+		return cached.value, cached.found
+	}
+	source.mutex.Lock()
+	retryAt, failed := source.failures[key]
+	source.mutex.Unlock()
+	if failed && time.Now().Before(retryAt) {
+		return "", false
+	}
+	value, found, err := getRemoteValue(source.client, key)
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+	if err != nil {
+		utils.LoggerOrNop(source.logger).Warn("Error in reading config", "key", key, "source", source.name, "error", err)
+		source.failures[key] = time.Now().Add(maxErrorTtl)
+		return "", false
+	}
+	delete(source.failures, key)
+	current := *source.values.Load()
+	next := make(map[string]remoteValue, len(current)+1)
+	for cachedKey, cachedValue := range current {
+		next[cachedKey] = cachedValue
+	}
+	next[key] = remoteValue{value: value, found: found}
+	source.values.Store(&next)
+	return value, found
+}
+
+// Snapshot freezes the keys fetched so far; keys never asked for are still fetched on demand.
+func (source *PollingRemoteSource) Snapshot() ConfigSource {
+	return &remoteSnapshot{parent: source, values: *source.values.Load()}
+}
+
+func (source *PollingRemoteSource) Reload() (bool, error) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+	current := *source.values.Load()
+	next := make(map[string]remoteValue, len(current))
+	changed := false
+	var failures []string
+	for key, previous := range current {
+		value, found, err := getRemoteValue(source.client, key)
+		if err != nil {
+			failures = append(failures, key)
+			next[key] = previous
+			continue
+		}
+		next[key] = remoteValue{value: value, found: found}
+		changed = changed || next[key] != previous
+	}
+	if changed {
+		source.values.Store(&next)
+	}
+	if len(failures) > 0 {
+		return changed, fmt.Errorf("could not poll %s for %s", source.name, strings.Join(failures, ", "))
+	}
+	return changed, nil
+}
+
+type remoteSnapshot struct {
+	parent *PollingRemoteSource
+	values map[string]remoteValue
+}
+
+func (snapshot *remoteSnapshot) Name() string {
+	return snapshot.parent.name
+}
+
+func (snapshot *remoteSnapshot) Lookup(key string) (string, bool) {
+	if cached, ok := snapshot.values[key]; ok {
+		return cached.value, cached.found
+	}
+	return snapshot.parent.Lookup(key)
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	secretstypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// remoteLookupTimeout bounds one call to a remote store, so a slow store can't hold up a config read.
+const remoteLookupTimeout = 5 * time.Second
+
+// maxErrorTtl bounds how long a failed remote lookup is remembered, so a throttled or unreachable store
+// is not called on every read but a recovered one is picked up quickly.
+const maxErrorTtl = 5 * time.Second
+
+// RemoteValueClient fetches a single value from a remote store. found is false when the key does not exist.
+type RemoteValueClient interface {
+	GetValue(ctx context.Context, key string) (value string, found bool, err error)
+}
+
+// RemoteSource adapts a RemoteValueClient to a ConfigSource. Each key is fetched once and the answer,
+// including a miss, is remembered; use ProvidePollingRemoteSource to pick up changes. Errors are logged
+// and treated as a miss so the next source gets a chance to answer; the key is fetched again once
+// maxErrorTtl has passed.
+type RemoteSource struct {
+	name   string
+	client RemoteValueClient
+	mutex  sync.Mutex
+	values map[string]remoteValue
+	// failures holds when each failed key may be fetched again.
+	failures map[string]time.Time
+	logger   *slog.Logger
+}
+
+func ProvideRemoteSource(name string, client RemoteValueClient) *RemoteSource {
+	return &RemoteSource{name: name, client: client, values: map[string]remoteValue{}, failures: map[string]time.Time{}}
+}
+
+// WithLogger sets the logger that hears about failed lookups; nothing is logged without one.
+func (source *RemoteSource) WithLogger(logger *slog.Logger) *RemoteSource {
+	source.logger = logger
+	return source
+}
+
+func (source *RemoteSource) Name() string {
+	return source.name
+}
+
+func (source *RemoteSource) Lookup(key string) (string, bool) {
+	source.mutex.Lock()
+	cached, ok := source.values[key]
+	retryAt, failed := source.failures[key]
+	source.mutex.Unlock()
+	if ok {
+		return cached.value, cached.found
+	}
+	if failed && time.Now().Before(retryAt) {
+		return "", false
+	}
+	value, found, err := getRemoteValue(source.client, key)
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+	if err != nil {
+		utils.LoggerOrNop(source.logger).Warn("Error in reading config", "key", key, "source", source.name, "error", err)
+		source.failures[key] = time.Now().Add(maxErrorTtl)
+		return "", false
+	}
+	delete(source.failures, key)
+	source.values[key] = remoteValue{value: value, found: found}
+	return value, found
+}
+
+// getRemoteValue fetches key with remoteLookupTimeout.
+func getRemoteValue(client RemoteValueClient, key string) (string, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteLookupTimeout)
+	defer cancel()
+	return client.GetValue(ctx, key)
+}
+
+// SsmParameterClient reads decrypted parameters named prefix + key from SSM Parameter Store.
+type SsmParameterClient struct {
+	client *ssm.Client
+	prefix string
+}
+
+func ProvideSsmParameterClient(provider ConfigProvider, prefix string) RemoteValueClient {
+	return &SsmParameterClient{
+		client: ssm.NewFromConfig(provider.SdkConfig, func(options *ssm.Options) {
+			options.BaseEndpoint = provider.Environment.BaseEndpoint(ServiceSsm)
+		}),
+		prefix: prefix,
+	}
+}
+
+func (parameterClient *SsmParameterClient) GetValue(ctx context.Context, key string) (string, bool, error) {
+	output, err := parameterClient.client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(parameterClient.prefix + key),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		var notFound *ssmtypes.ParameterNotFound
+		if errors.As(err, &notFound) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return aws.ToString(output.Parameter.Value), true, nil
+}
+
+// SecretsManagerClient reads the string value of secrets named prefix + key from Secrets Manager.
+type SecretsManagerClient struct {
+	client *secretsmanager.Client
+	prefix string
+}
+
+func ProvideSecretsManagerClient(provider ConfigProvider, prefix string) RemoteValueClient {
+	return &SecretsManagerClient{
+		client: secretsmanager.NewFromConfig(provider.SdkConfig, func(options *secretsmanager.Options) {
+			options.BaseEndpoint = provider.Environment.BaseEndpoint(ServiceSecretsManager)
+		}),
+		prefix: prefix,
+	}
+}
+
+func (secretsClient *SecretsManagerClient) GetValue(ctx context.Context, key string) (string, bool, error) {
+	output, err := secretsClient.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretsClient.prefix + key),
+	})
+	if err != nil {
+		var notFound *secretstypes.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return aws.ToString(output.SecretString), true, nil
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type countingClient struct {
+	values map[string]string
+	err    error
+	calls  map[string]int
+	// withoutDeadline counts the calls whose context had no deadline.
+	withoutDeadline int
+}
+
+func (client *countingClient) GetValue(ctx context.Context, key string) (string, bool, error) {
+	client.calls[key]++
+	if _, ok := ctx.Deadline(); !ok {
+		client.withoutDeadline++
+	}
+	if client.err != nil {
+		return "", false, client.err
+	}
+	value, found := client.values[key]
+	return value, found, nil
+}
+
+func TestRemoteSourceCachesHitsAndMisses(t *testing.T) {
+	client := &countingClient{values: map[string]string{"TABLE": "orders"}, calls: map[string]int{}}
+	source := ProvideRemoteSource("ssm", client)
+	for i := 0; i < 3; i++ {
+		if value, found := source.Lookup("TABLE"); !found || value != "orders" {
+			t.Fatalf("Lookup(TABLE) = %q, %v", value, found)
+		}
+		if _, found := source.Lookup("MISSING"); found {
+			t.Fatal("Lookup(MISSING) found a value")
+		}
+	}
+	if client.calls["TABLE"] != 1 || client.calls["MISSING"] != 1 {
+		t.Fatalf("client calls = %v, want one per key", client.calls)
+	}
+}
+
+func TestRemoteSourceCachesErrorsBriefly(t *testing.T) {
+	client := &countingClient{err: errors.New("throttled"), calls: map[string]int{}}
+	source := ProvideRemoteSource("ssm", client)
+	for i := 0; i < 3; i++ {
+		if _, found := source.Lookup("TABLE"); found {
+			t.Fatal("Lookup found a value while the store fails")
+		}
+	}
+	if client.calls["TABLE"] != 1 {
+		t.Fatalf("client calls = %d, want the error to be cached", client.calls["TABLE"])
+	}
+	if ttl := time.Until(source.failures["TABLE"]); ttl > maxErrorTtl {
+		t.Fatalf("error cached for %s, want at most %s", ttl, maxErrorTtl)
+	}
+
+	client.err = nil
+	client.values = map[string]string{"TABLE": "orders"}
+	source.failures["TABLE"] = time.Now()
+	if value, found := source.Lookup("TABLE"); !found || value != "orders" {
+		t.Fatalf("Lookup after recovery = %q, %v", value, found)
+	}
+	if len(source.failures) != 0 {
+		t.Fatalf("failures = %v, want the recovered key forgotten", source.failures)
+	}
+}
+
+func TestPollingRemoteSourceCachesErrorsBriefly(t *testing.T) {
+	client := &countingClient{err: errors.New("throttled"), calls: map[string]int{}}
+	source := ProvidePollingRemoteSource("ssm", client)
+	source.Lookup("TABLE")
+	source.Lookup("TABLE")
+	if client.calls["TABLE"] != 1 {
+		t.Fatalf("client calls = %d, want the error to be cached", client.calls["TABLE"])
+	}
+	client.err = nil
+	client.values = map[string]string{"TABLE": "orders"}
+	source.failures["TABLE"] = time.Now()
+	if value, found := source.Lookup("TABLE"); !found || value != "orders" {
+		t.Fatalf("Lookup after recovery = %q, %v", value, found)
+	}
+}
+
+func TestRemoteLookupsHaveADeadline(t *testing.T) {
+	client := &countingClient{values: map[string]string{"TABLE": "orders"}, calls: map[string]int{}}
+	ProvideRemoteSource("ssm", client).Lookup("TABLE")
+	if client.withoutDeadline > 0 {
+		t.Fatal("RemoteSource fetched without a deadline")
+	}
+	client = &countingClient{values: map[string]string{"TABLE": "orders"}, calls: map[string]int{}}
+	source := ProvidePollingRemoteSource("ssm", client)
+	source.Lookup("TABLE")
+	source.Reload()
+	if client.calls["TABLE"] != 2 || client.withoutDeadline > 0 {
+		t.Fatal("PollingRemoteSource fetched without a deadline")
+	}
+}
@@ -0,0 +1,241 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+// SecretValue holds a resolved secret. It prints as [REDACTED] everywhere; call Reveal to use it.
+type SecretValue struct {
+	value string
+}
+
+func NewSecretValue(value string) SecretValue {
+	return SecretValue{value: value}
+}
+
+func (secret SecretValue) Reveal() string {
+	return secret.value
+}
+
+func (secret SecretValue) String() string {
+	return redacted
+}
+
+func (secret SecretValue) GoString() string {
+	return redacted
+}
+
+func (secret SecretValue) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
+
+func (secret SecretValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(redacted)
+}
+
+// SecretConfigContract reads config values that must not be printed; use AsSecretConfig to get one for
+// any repository.
+type SecretConfigContract interface {
+	// GetSecret resolves secret references and wraps the value so it is redacted when printed. It fails
+	// when the key is not set or its reference can't be resolved.
+	GetSecret(key string) (SecretValue, error)
+}
+
+// AsSecretConfig returns repository itself when it implements SecretConfigContract, and an adapter that
+// wraps the values of GetString otherwise.
+func AsSecretConfig(repository ConfigRepositoryContract) SecretConfigContract {
+	if secrets, ok := repository.(SecretConfigContract); ok {
+		return secrets
+	}
+	return repositoryView{repository: repository}
+}
+
+func (view repositoryView) GetSecret(key string) (SecretValue, error) {
+	value, _, found := view.Lookup(key)
+	if !found {
+		return SecretValue{}, fmt.Errorf("config key %s is not set", key)
+	}
+	return NewSecretValue(value), nil
+}
+
+type cachedSecret struct {
+	value     string
+	fetchedAt time.Time
+}
+
+type failedSecret struct {
+	err     error
+	retryAt time.Time
+}
+
+// SecretResolver resolves references such as secret://name#field and ssm://path through the client
+// registered for the scheme. A "#field" suffix reads that field of a JSON secret. Values are cached
+// for ttl and, when a refresh interval is set, refreshed in the background so rotations are picked up.
+// When a refresh fails, the last value is served until a later refresh succeeds. A reference that
+// never resolved returns its error again, without a fetch, for ttl but at most maxErrorTtl. Each fetch
+// is bounded by remoteLookupTimeout.
+type SecretResolver struct {
+	clients  map[string]RemoteValueClient
+	ttl      time.Duration
+	mutex    sync.RWMutex
+	cache    map[string]cachedSecret
+	failures map[string]failedSecret
+	stop     chan struct{}
+	once     sync.Once
+	logger   atomic.Pointer[slog.Logger]
+}
+
+func ProvideSecretResolver(clients map[string]RemoteValueClient, ttl time.Duration, refreshInterval time.Duration) *SecretResolver {
+	resolver := &SecretResolver{
+		clients:  clients,
+		ttl:      ttl,
+		cache:    map[string]cachedSecret{},
+		failures: map[string]failedSecret{},
+		stop:     make(chan struct{}),
+	}
+	if refreshInterval > 0 {
+		go resolver.refreshLoop(refreshInterval)
+	}
+	return resolver
+}
+
+// ProvideAwsSecretResolver resolves secret:// through Secrets Manager and ssm:// through Parameter Store.
+func ProvideAwsSecretResolver(provider ConfigProvider, ttl time.Duration, refreshInterval time.Duration) *SecretResolver {
+	return ProvideSecretResolver(map[string]RemoteValueClient{
+		"secret": ProvideSecretsManagerClient(provider, ""),
+		"ssm":    ProvideSsmParameterClient(provider, ""),
+	}, ttl, refreshInterval)
+}
+
+// WithLogger sets the logger that hears about failed refreshes; nothing is logged without one.
+func (resolver *SecretResolver) WithLogger(logger *slog.Logger) *SecretResolver {
+	resolver.logger.Store(logger)
+	return resolver
+}
+
+func (resolver *SecretResolver) Resolve(ctx context.Context, reference string) (SecretValue, error) {
+	resolver.mutex.RLock()
+	cached, ok := resolver.cache[reference]
+	failed, hasFailed := resolver.failures[reference]
+	resolver.mutex.RUnlock()
+	if ok && (resolver.ttl <= 0 || time.Since(cached.fetchedAt) < resolver.ttl) {
+		return NewSecretValue(cached.value), nil
+	}
+	if !ok && hasFailed && time.Now().Before(failed.retryAt) {
+		return SecretValue{}, failed.err
+	}
+	value, err := resolver.fetch(ctx, reference)
+	if err != nil && ok {
+		utils.LoggerOrNop(resolver.logger.Load()).Warn("Error in refreshing secret, serving the previous value", "error", err)
+		return NewSecretValue(cached.value), nil
+	}
+	if err != nil {
+		errorTtl := maxErrorTtl
+		if resolver.ttl > 0 {
+			errorTtl = min(resolver.ttl, maxErrorTtl)
+		}
+		resolver.mutex.Lock()
+		resolver.failures[reference] = failedSecret{err: err, retryAt: time.Now().Add(errorTtl)}
+		resolver.mutex.Unlock()
+		return SecretValue{}, err
+	}
+	resolver.mutex.Lock()
+	delete(resolver.failures, reference)
+	resolver.cache[reference] = cachedSecret{value: value, fetchedAt: time.Now()}
+	resolver.mutex.Unlock()
+	return NewSecretValue(value), nil
+}
+
+// IsSecretReference reports whether value has the form scheme://name with a scheme the resolver knows.
+func (resolver *SecretResolver) IsSecretReference(value string) bool {
+	scheme, _, found := strings.Cut(value, "://")
+	if !found {
+		return false
+	}
+	_, known := resolver.clients[scheme]
+	return known
+}
+
+// Close stops the background refresh.
+func (resolver *SecretResolver) Close() {
+	resolver.once.Do(func() {
+		close(resolver.stop)
+	})
+}
+
+func (resolver *SecretResolver) fetch(ctx context.Context, reference string) (string, error) {
+	scheme, rest, found := strings.Cut(reference, "://")
+	client, known := resolver.clients[scheme]
+	if !found || !known {
+		return "", fmt.Errorf("unsupported secret reference scheme: %s", scheme)
+	}
+	name, field, hasField := strings.Cut(rest, "#")
+	ctx, cancel := context.WithTimeout(ctx, remoteLookupTimeout)
+	defer cancel()
+	value, found, err := client.GetValue(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve %s://%s: %w", scheme, name, err)
+	}
+	if !found {
+		return "", fmt.Errorf("secret %s://%s does not exist", scheme, name)
+	}
+	if !hasField {
+		return value, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("secret %s://%s is not a JSON object", scheme, name)
+	}
+	fieldValue, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("secret %s://%s has no field %s", scheme, name, field)
+	}
+	if text, ok := fieldValue.(string); ok {
+		return text, nil
+	}
+	encoded, err := json.Marshal(fieldValue)
+	return string(encoded), err
+}
+
+func (resolver *SecretResolver) refreshLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-resolver.stop:
+			return
+		case <-ticker.C:
+			resolver.refresh()
+		}
+	}
+}
+
+// refresh refetches every cached reference, keeping the previous value when a fetch fails.
+func (resolver *SecretResolver) refresh() {
+	resolver.mutex.RLock()
+	references := make([]string, 0, len(resolver.cache))
+	for reference := range resolver.cache {
+		references = append(references, reference)
+	}
+	resolver.mutex.RUnlock()
+	for _, reference := range references {
+		value, err := resolver.fetch(context.TODO(), reference)
+		if err != nil {
+			utils.LoggerOrNop(resolver.logger.Load()).Warn("Error in refreshing secret", "error", err)
+			continue
+		}
+		resolver.mutex.Lock()
+		resolver.cache[reference] = cachedSecret{value: value, fetchedAt: time.Now()}
+		resolver.mutex.Unlock()
+	}
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func secretRepository(client *countingClient) *ConfigRepository {
+	resolver := ProvideSecretResolver(map[string]RemoteValueClient{"secret": client}, time.Nanosecond, 0)
+	return ProvideConfigRepository(ProvideMapSource("test", map[string]string{
+		"DB_PASSWORD": "secret://db#password",
+		"TABLE_NAME":  "orders",
+	})).WithSecretResolver(resolver)
+}
+
+func TestSecretValueIsRedacted(t *testing.T) {
+	secret := NewSecretValue("hunter2")
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		if printed := fmt.Sprintf(format, secret); printed != redacted {
+			t.Fatalf("%s printed the secret as %q", format, printed)
+		}
+	}
+	if secret.Reveal() != "hunter2" {
+		t.Fatal("Reveal did not return the value")
+	}
+}
+
+func TestSecretResolutionErrorsArePropagated(t *testing.T) {
+	client := &countingClient{err: errors.New("access denied"), calls: map[string]int{}}
+	repository := secretRepository(client)
+
+	if _, source, found, err := repository.Resolve("DB_PASSWORD"); err == nil || !found || source != "test" {
+		t.Fatalf("Resolve = %q, %v, %v, want the resolution error", source, found, err)
+	}
+	if _, err := repository.GetSecret("DB_PASSWORD"); err == nil {
+		t.Fatal("expected GetSecret to fail")
+	}
+	if value := repository.GetString("DB_PASSWORD", "fallback"); value != "fallback" {
+		t.Fatalf("GetString = %q, want the default", value)
+	}
+
+	var settings struct {
+		Password string `config:"DB_PASSWORD" required:"true"`
+	}
+	report, _ := Validate(ConfigProvider{ConfigRepository: repository}, &settings)
+	for _, entry := range report.Entries {
+		if entry.Key == "DB_PASSWORD" && len(entry.Problem) == 0 {
+			t.Fatal("validation did not report the unresolved secret")
+		}
+	}
+}
+
+func TestSecretResolverServesStaleValueWhenRefreshFails(t *testing.T) {
+	client := &countingClient{values: map[string]string{"db": `{"password": "first"}`}, calls: map[string]int{}}
+	repository := secretRepository(client)
+	secret, err := repository.GetSecret("DB_PASSWORD")
+	if err != nil || secret.Reveal() != "first" {
+		t.Fatalf("GetSecret = %v, %v", secret.Reveal(), err)
+	}
+	client.err = errors.New("throttled")
+	time.Sleep(time.Millisecond)
+	secret, err = repository.GetSecret("DB_PASSWORD")
+	if err != nil || secret.Reveal() != "first" {
+		t.Fatalf("GetSecret after a failed refresh = %q, %v, want the previous value", secret.Reveal(), err)
+	}
+	if client.calls["db"] != 2 {
+		t.Fatalf("client calls = %d, want the expired value to be fetched again", client.calls["db"])
+	}
+}
+
+func TestSecretResolverCachesErrorsBriefly(t *testing.T) {
+	client := &countingClient{err: errors.New("throttled"), calls: map[string]int{}}
+	resolver := ProvideSecretResolver(map[string]RemoteValueClient{"secret": client}, time.Minute, 0)
+	for i := 0; i < 3; i++ {
+		if _, err := resolver.Resolve(context.Background(), "secret://db"); err == nil {
+			t.Fatal("expected the resolution error")
+		}
+	}
+	if client.calls["db"] != 1 || client.withoutDeadline > 0 {
+		t.Fatalf("client calls = %v, %d without a deadline, want one call with a deadline", client.calls, client.withoutDeadline)
+	}
+	if ttl := time.Until(resolver.failures["secret://db"].retryAt); ttl > maxErrorTtl {
+		t.Fatalf("error cached for %s, want at most %s", ttl, maxErrorTtl)
+	}
+
+	client.err = nil
+	client.values = map[string]string{"db": "hunter2"}
+	resolver.failures["secret://db"] = failedSecret{retryAt: time.Now()}
+	if secret, err := resolver.Resolve(context.Background(), "secret://db"); err != nil || secret.Reveal() != "hunter2" {
+		t.Fatalf("Resolve after recovery = %v", err)
+	}
+}
+
+func TestTypedGettersAndBindReturnResolutionErrors(t *testing.T) {
+	client := &countingClient{err: errors.New("kms access denied"), calls: map[string]int{}}
+	repository := ProvideConfigRepository(ProvideMapSource("test", map[string]string{
+		"DB_PORT":     "ssm://db/port",
+		"DB_PASSWORD": "secret://db#password",
+	})).WithSecretResolver(ProvideSecretResolver(map[string]RemoteValueClient{"secret": client, "ssm": client}, time.Minute, 0))
+
+	if port, err := repository.GetInt("DB_PORT", 5432); err == nil || port != 5432 {
+		t.Fatalf("GetInt = %d, %v, want the default with the resolution error", port, err)
+	}
+	if retries, err := repository.GetInt("RETRIES", 3); err != nil || retries != 3 {
+		t.Fatalf("GetInt of a missing key = %d, %v, want the default without an error", retries, err)
+	}
+	var settings struct {
+		Password string `config:"DB_PASSWORD" default:"postgres"`
+		Retries  int    `config:"RETRIES" default:"3"`
+	}
+	err := repository.Bind(&settings)
+	if err == nil || !strings.Contains(err.Error(), "DB_PASSWORD") || !strings.Contains(err.Error(), "kms access denied") {
+		t.Fatalf("Bind = %v, want the resolution error of DB_PASSWORD", err)
+	}
+	if settings.Password != "" || settings.Retries != 3 {
+		t.Fatalf("settings = %+v, want no default for the failed secret and the default for the missing key", settings)
+	}
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TypedConfigContract parses config values into Go types; use AsTypedConfig to get one for any repository.
+type TypedConfigContract interface {
+	GetInt(key string, defaultValue int) (int, error)
+	GetBool(key string, defaultValue bool) (bool, error)
+	GetFloat(key string, defaultValue float64) (float64, error)
+	GetDuration(key string, defaultValue time.Duration) (time.Duration, error)
+	GetStringSlice(key string, defaultValue []string) ([]string, error)
+	GetURL(key string, defaultValue *url.URL) (*url.URL, error)
+}
+
+// AsTypedConfig returns repository itself when it implements TypedConfigContract, and an adapter that
+// parses the values of GetString otherwise.
+func AsTypedConfig(repository ConfigRepositoryContract) TypedConfigContract {
+	if typed, ok := repository.(TypedConfigContract); ok {
+		return typed
+	}
+	return repositoryView{repository: repository}
+}
+
+// Each typed getter returns defaultValue when the key is missing, and defaultValue with an error naming
+// the key when the value can't be resolved or parsed.
+
+func (snapshot *ConfigSnapshot) GetInt(key string, defaultValue int) (int, error) {
+	return getTyped(snapshot, key, defaultValue, strconv.Atoi)
+}
+
+func (snapshot *ConfigSnapshot) GetBool(key string, defaultValue bool) (bool, error) {
+	return getTyped(snapshot, key, defaultValue, strconv.ParseBool)
+}
+
+func (snapshot *ConfigSnapshot) GetFloat(key string, defaultValue float64) (float64, error) {
+	return getTyped(snapshot, key, defaultValue, parseFloat)
+}
+
+func (snapshot *ConfigSnapshot) GetDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	return getTyped(snapshot, key, defaultValue, time.ParseDuration)
+}
+
+// GetStringSlice splits the value on commas and trims the spaces around each element.
+func (snapshot *ConfigSnapshot) GetStringSlice(key string, defaultValue []string) ([]string, error) {
+	return getTyped(snapshot, key, defaultValue, parseStringSlice)
+}
+
+// GetURL only accepts absolute URLs.
+func (snapshot *ConfigSnapshot) GetURL(key string, defaultValue *url.URL) (*url.URL, error) {
+	return getTyped(snapshot, key, defaultValue, parseAbsoluteUrl)
+}
+
+func (repo *ConfigRepository) GetInt(key string, defaultValue int) (int, error) {
+	return repo.live().GetInt(key, defaultValue)
+}
+
+func (repo *ConfigRepository) GetBool(key string, defaultValue bool) (bool, error) {
+	return repo.live().GetBool(key, defaultValue)
+}
+
+func (repo *ConfigRepository) GetFloat(key string, defaultValue float64) (float64, error) {
+	return repo.live().GetFloat(key, defaultValue)
+}
+
+func (repo *ConfigRepository) GetDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	return repo.live().GetDuration(key, defaultValue)
+}
+
+func (repo *ConfigRepository) GetStringSlice(key string, defaultValue []string) ([]string, error) {
+	return repo.live().GetStringSlice(key, defaultValue)
+}
+
+func (repo *ConfigRepository) GetURL(key string, defaultValue *url.URL) (*url.URL, error) {
+	return repo.live().GetURL(key, defaultValue)
+}
+
+func (view repositoryView) GetInt(key string, defaultValue int) (int, error) {
+	return getTyped(view, key, defaultValue, strconv.Atoi)
+}
+
+func (view repositoryView) GetBool(key string, defaultValue bool) (bool, error) {
+	return getTyped(view, key, defaultValue, strconv.ParseBool)
+}
+
+func (view repositoryView) GetFloat(key string, defaultValue float64) (float64, error) {
+	return getTyped(view, key, defaultValue, parseFloat)
+}
+
+func (view repositoryView) GetDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	return getTyped(view, key, defaultValue, time.ParseDuration)
+}
+
+func (view repositoryView) GetStringSlice(key string, defaultValue []string) ([]string, error) {
+	return getTyped(view, key, defaultValue, parseStringSlice)
+}
+
+func (view repositoryView) GetURL(key string, defaultValue *url.URL) (*url.URL, error) {
+	return getTyped(view, key, defaultValue, parseAbsoluteUrl)
+}
+
+func getTyped[V any](repo ValueLookup, key string, defaultValue V, parse func(string) (V, error)) (V, error) {
+	raw, _, found, err := resolveValue(repo, key)
+	if err != nil {
+		return defaultValue, err
+	}
+	if !found {
+		return defaultValue, nil
+	}
+	value, err := parse(raw)
+	if err != nil {
+		return defaultValue, fmt.Errorf("config key %s: %w", key, err)
+	}
+	return value, nil
+}
+
+func parseFloat(raw string) (float64, error) {
+	return strconv.ParseFloat(raw, 64)
+}
+
+func parseStringSlice(raw string) ([]string, error) {
+	if len(strings.TrimSpace(raw)) == 0 {
+		return []string{}, nil
+	}
+	parts := strings.Split(raw, ",")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	return parts, nil
+}
+
+func parseAbsoluteUrl(raw string) (*url.URL, error) {
+	parsedUrl, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !parsedUrl.IsAbs() || len(parsedUrl.Host) == 0 {
+		return nil, fmt.Errorf("%q is not an absolute URL", raw)
+	}
+	return parsedUrl, nil
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+// stringRepository only implements ConfigRepositoryContract.
+type stringRepository map[string]string
+
+func (repository stringRepository) GetString(key string, defaultValue string) string {
+	if value, ok := repository[key]; ok {
+		return value
+	}
+	return defaultValue
+}
+
+func TestAsTypedConfigOverGetString(t *testing.T) {
+	typed := AsTypedConfig(stringRepository{"RETRIES": "3", "TIMEOUT": "2s", "BROKEN": "three", "EMPTY": ""})
+	if retries, err := typed.GetInt("RETRIES", 1); err != nil || retries != 3 {
+		t.Fatalf("GetInt(RETRIES) = %d, %v", retries, err)
+	}
+	if timeout, err := typed.GetDuration("TIMEOUT", time.Second); err != nil || timeout != 2*time.Second {
+		t.Fatalf("GetDuration(TIMEOUT) = %v, %v", timeout, err)
+	}
+	if missing, err := typed.GetInt("MISSING", 7); err != nil || missing != 7 {
+		t.Fatalf("GetInt(MISSING) = %d, %v, want the default", missing, err)
+	}
+	if _, err := typed.GetInt("BROKEN", 1); err == nil {
+		t.Fatal("expected an error for a malformed value")
+	}
+	if tags, err := typed.GetStringSlice("EMPTY", []string{"default"}); err != nil || len(tags) != 0 {
+		t.Fatalf("GetStringSlice(EMPTY) = %v, %v, want an empty value rather than the default", tags, err)
+	}
+}
+
+func TestBindOverGetString(t *testing.T) {
+	var settings struct {
+		Table   string        `config:"TABLE_NAME" required:"true"`
+		Timeout time.Duration `config:"TIMEOUT" default:"5s"`
+	}
+	if err := Bind(AsValueLookup(stringRepository{"TABLE_NAME": "orders"}), &settings); err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+	if settings.Table != "orders" || settings.Timeout != 5*time.Second {
+		t.Fatalf("Bind = %+v", settings)
+	}
+	if err := Bind(AsValueLookup(stringRepository{}), &settings); err == nil {
+		t.Fatal("expected Bind to report the missing required key")
+	}
+}
@@ -0,0 +1,259 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const credentialCheckTimeout = 5 * time.Second
+
+var (
+	regionPattern    = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+	sensitivePattern = regexp.MustCompile(`(?i)(SECRET|PASSWORD|TOKEN|ACCESS_KEY|API_KEY|PRIVATE)`)
+)
+
+// ReportEntry describes how one config key was resolved.
+type ReportEntry struct {
+	Key         string
+	Value       string
+	Source      string
+	UsedDefault bool
+	Sensitive   bool
+	Problem     string
+}
+
+// ValidationReport lists every key Validate looked at and whether the credentials resolved.
+type ValidationReport struct {
+	Entries         []ReportEntry
+	CredentialError string
+}
+
+// providerKeys are the keys OptionsFromRepository reads, with the default each one falls back to.
+var providerKeys = []struct {
+	key          string
+	defaultValue string
+}{
+	{"ENVIRONMENT_PROFILE", ""},
+	{"PROFILE", ""},
+	{"ACCESS_KEY_ID", ""},
+	{"SECRET_KEY", ""},
+	{"SESSION_TOKEN", ""},
+	{"ASSUME_ROLE_ARN", ""},
+	{"ASSUME_ROLE_EXTERNAL_ID", ""},
+	{"ASSUME_ROLE_SESSION_NAME", ""},
+	{"ENDPOINT_URL", ""},
+}
+
+// Validate checks the keys ConfigProvider reads, every key tagged in spec (the same
+// `config:"KEY" default:"..." required:"true"` tags Bind uses, plus `secret:"true"` to force redaction)
+// and that the provider's credentials resolve. The report is returned even when validation fails.
+func Validate(provider ConfigProvider, spec any) (*ValidationReport, error) {
+	repository := provider.ConfigRepository
+	if repository == nil {
+		repository = &ConfigRepository{}
+	}
+	snapshot := SnapshotOf(repository)
+	report := &ValidationReport{Entries: []ReportEntry{regionEntry(provider, snapshot)}}
+	for _, providerKey := range providerKeys {
+		entry, value := resolveEntry(snapshot, providerKey.key, providerKey.defaultValue, providerKey.defaultValue != "", false)
+		if providerKey.key == "ENDPOINT_URL" && len(entry.Problem) == 0 && len(value) > 0 {
+			if _, err := parseAbsoluteUrl(value); err != nil {
+				entry.Problem = "is not an absolute URL such as http://localhost:4566"
+			}
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+	if spec != nil {
+		specType := reflect.TypeOf(spec)
+		if specType.Kind() == reflect.Pointer {
+			specType = specType.Elem()
+		}
+		if specType.Kind() != reflect.Struct {
+			return report, errors.New("validation spec must be a struct")
+		}
+		report.Entries = append(report.Entries, specEntries(snapshot, specType)...)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), credentialCheckTimeout)
+	defer cancel()
+	if provider.SdkConfig.Credentials == nil {
+		report.CredentialError = "no credentials provider configured"
+	} else if _, err := provider.SdkConfig.Credentials.Retrieve(ctx); err != nil {
+		report.CredentialError = err.Error()
+	}
+	return report, report.Err()
+}
+
+// Err joins every problem in the report, or returns nil when there are none.
+func (report *ValidationReport) Err() error {
+	var problems []error
+	for _, entry := range report.Entries {
+		if len(entry.Problem) > 0 {
+			problems = append(problems, fmt.Errorf("config key %s: %s", entry.Key, entry.Problem))
+		}
+	}
+	if len(report.CredentialError) > 0 {
+		problems = append(problems, fmt.Errorf("credentials could not be resolved: %s", report.CredentialError))
+	}
+	return errors.Join(problems...)
+}
+
+// WriteTo writes the report as a table. Sensitive values are always redacted.
+func (report *ValidationReport) WriteTo(writer io.Writer) (int64, error) {
+	var builder strings.Builder
+	table := tabwriter.NewWriter(&builder, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "KEY\tVALUE\tSOURCE\tDEFAULT\tPROBLEM")
+	for _, entry := range report.Entries {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%t\t%s\n", entry.Key, entry.displayValue(), entry.Source, entry.UsedDefault, entry.Problem)
+	}
+	table.Flush()
+	credentials := "resolved"
+	if len(report.CredentialError) > 0 {
+		credentials = "FAILED: " + report.CredentialError
+	}
+	fmt.Fprintf(&builder, "credentials: %s\n", credentials)
+	written, err := io.WriteString(writer, builder.String())
+	return int64(written), err
+}
+
+func (report *ValidationReport) String() string {
+	var builder strings.Builder
+	report.WriteTo(&builder)
+	return builder.String()
+}
+
+// Log prints the report line by line through the standard logger.
+func (report *ValidationReport) Log() {
+	for _, line := range strings.Split(strings.TrimRight(report.String(), "\n"), "\n") {
+		log.Println(line)
+	}
+}
+
+func (entry ReportEntry) displayValue() string {
+	switch {
+	case len(entry.Value) == 0:
+		return "<unset>"
+	case entry.Sensitive:
+		return redacted
+	default:
+		return entry.Value
+	}
+}
+
+func specEntries(snapshot ConfigView, specType reflect.Type) []ReportEntry {
+	var entries []ReportEntry
+	for i := 0; i < specType.NumField(); i++ {
+		field := specType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key, tagged := field.Tag.Lookup("config")
+		if !tagged {
+			if field.Type.Kind() == reflect.Struct && field.Type != urlType {
+				entries = append(entries, specEntries(snapshot, field.Type)...)
+			}
+			continue
+		}
+		defaultValue, hasDefault := field.Tag.Lookup("default")
+		entry, value := resolveEntry(snapshot, key, defaultValue, hasDefault, field.Tag.Get("secret") == "true")
+		switch {
+		case len(entry.Problem) > 0:
+		case len(entry.Source) == 0 && !hasDefault && field.Tag.Get("required") == "true":
+			entry.Problem = "is required"
+		case len(entry.Source) > 0:
+			scratch := reflect.New(field.Type).Elem()
+			if err := setField(scratch, value); err != nil {
+				entry.Problem = err.Error()
+				if entry.Sensitive {
+					// The parse error quotes the value.
+					entry.Problem = fmt.Sprintf("is not a valid %s", field.Type)
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// resolveEntry also returns the resolved value, which may be a revealed secret. The entry itself never
+// holds one: a secret reference is reported as the reference and marked sensitive.
+func resolveEntry(snapshot ConfigView, key string, defaultValue string, hasDefault bool, secret bool) (ReportEntry, string) {
+	entry := ReportEntry{Key: key, Sensitive: secret || sensitivePattern.MatchString(key)}
+	raw, _, secretReference, _ := lookupRaw(snapshot, key)
+	if secretReference {
+		entry.Sensitive = true
+	}
+	value, source, found, err := resolveValue(snapshot, key)
+	switch {
+	case err != nil:
+		entry.Source, entry.Sensitive, entry.Problem = source, true, err.Error()
+	case found && secretReference:
+		entry.Value, entry.Source = raw, source
+	case found:
+		entry.Value, entry.Source = value, source
+	case hasDefault:
+		entry.Value, entry.Source, entry.UsedDefault = defaultValue, "default", true
+		value = defaultValue
+	}
+	return entry, value
+}
+
+// regionEntry reports the region the SDK config ended up with and where it came from: REGION, the
+// environment profile, AWS_REGION, the shared config or the library default.
+func regionEntry(provider ConfigProvider, snapshot ConfigView) ReportEntry {
+	entry, _ := resolveEntry(snapshot, "REGION", "", false, false)
+	if len(entry.Problem) > 0 {
+		return entry
+	}
+	region := provider.SdkConfig.Region
+	profile, hasProfile := LookupEnvironmentProfile(provider.Environment.Name)
+	switch {
+	case len(region) == 0:
+		entry.Problem = "no region is configured"
+		return entry
+	case len(entry.Source) > 0 && entry.Value == region:
+	case hasProfile && profile.Region == region:
+		entry.Source = "environment profile " + profile.Name
+	case os.Getenv("AWS_REGION") == region:
+		entry.Source = "AWS_REGION"
+	case region == defaultRegion:
+		entry.Source, entry.UsedDefault = "default", true
+	default:
+		entry.Source = "shared config"
+	}
+	entry.Value = region
+	if !regionPattern.MatchString(region) {
+		entry.Problem = fmt.Sprintf("%q is not an AWS region", region)
+	}
+	return entry
+}
+
+// lookupRaw reads key without resolving secret references when snapshot can, as ConfigSnapshot does.
+func lookupRaw(snapshot ConfigView, key string) (string, string, bool, bool) {
+	if rawLookup, ok := snapshot.(interface {
+		LookupRaw(key string) (string, string, bool, bool)
+	}); ok {
+		return rawLookup.LookupRaw(key)
+	}
+	return "", "", false, false
+}
+
+// resolveValue reports secret resolution errors when lookup can, as ConfigRepository and ConfigSnapshot
+// do, so they are not mistaken for a missing key.
+func resolveValue(lookup ValueLookup, key string) (string, string, bool, error) {
+	if resolver, ok := lookup.(interface {
+		Resolve(key string) (string, string, bool, error)
+	}); ok {
+		return resolver.Resolve(key)
+	}
+	value, source, found := lookup.Lookup(key)
+	return value, source, found, nil
+}
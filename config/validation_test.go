@@ -0,0 +1,97 @@
+package config
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"strings"
+	"testing"
+	"time"
+)
+
+func reportEntry(t *testing.T, report *ValidationReport, key string) ReportEntry {
+	t.Helper()
+	for _, entry := range report.Entries {
+		if entry.Key == key {
+			return entry
+		}
+	}
+	t.Fatalf("no report entry for %s", key)
+	return ReportEntry{}
+}
+
+func TestValidateNeverReportsRevealedSecrets(t *testing.T) {
+	client := &countingClient{values: map[string]string{"db": `{"password": "hunter2", "port": "not a port"}`}, calls: map[string]int{}}
+	resolver := ProvideSecretResolver(map[string]RemoteValueClient{"secret": client}, time.Minute, 0)
+	repository := ProvideConfigRepository(ProvideMapSource("test", map[string]string{
+		"DB_PASSWORD": "secret://db#password",
+		"DB_PORT":     "secret://db#port",
+	})).WithSecretResolver(resolver)
+	var settings struct {
+		Password string `config:"DB_PASSWORD"`
+		Port     int    `config:"DB_PORT"`
+	}
+	report, _ := Validate(ConfigProvider{SdkConfig: aws.Config{Region: "us-west-2"}, ConfigRepository: repository}, &settings)
+
+	password := reportEntry(t, report, "DB_PASSWORD")
+	if !password.Sensitive || password.Value != "secret://db#password" || password.Source != "test" {
+		t.Fatalf("DB_PASSWORD entry = %+v, want the sensitive reference", password)
+	}
+	port := reportEntry(t, report, "DB_PORT")
+	if !port.Sensitive || len(port.Problem) == 0 {
+		t.Fatalf("DB_PORT entry = %+v, want a sensitive entry with a type problem", port)
+	}
+	for _, entry := range report.Entries {
+		if strings.Contains(entry.Value, "hunter2") || strings.Contains(entry.Problem, "not a port") {
+			t.Fatalf("entry %s holds a revealed secret: %+v", entry.Key, entry)
+		}
+	}
+}
+
+func TestValidateReportsTheSdkRegion(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	tests := map[string]struct {
+		values      map[string]string
+		environment string
+		region      string
+		source      string
+		usedDefault bool
+	}{
+		"from REGION":              {values: map[string]string{"REGION": "eu-west-1"}, region: "eu-west-1", source: "test"},
+		"from the profile":         {environment: EnvironmentLocal, region: "us-east-1", source: "environment profile local"},
+		"from the default":         {region: defaultRegion, source: "default", usedDefault: true},
+		"overridden by the caller": {values: map[string]string{"REGION": "eu-west-1"}, region: "ap-south-1", source: "shared config"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			provider := ConfigProvider{
+				SdkConfig:        aws.Config{Region: test.region},
+				ConfigRepository: ProvideConfigRepository(ProvideMapSource("test", test.values)),
+				Environment:      EnvironmentProfile{Name: test.environment},
+			}
+			report, _ := Validate(provider, nil)
+			entry := reportEntry(t, report, "REGION")
+			if entry.Value != test.region || entry.Source != test.source || entry.UsedDefault != test.usedDefault || len(entry.Problem) > 0 {
+				t.Fatalf("REGION entry = %+v, want %s from %s", entry, test.region, test.source)
+			}
+		})
+	}
+}
+
+func TestValidateChecksEndpointUrl(t *testing.T) {
+	tests := map[string]bool{
+		"http://localhost:4566": true,
+		"localhost:4566":        false,
+		"/relative":             false,
+	}
+	for endpoint, valid := range tests {
+		t.Run(endpoint, func(t *testing.T) {
+			provider := ConfigProvider{
+				SdkConfig:        aws.Config{Region: defaultRegion},
+				ConfigRepository: ProvideConfigRepository(ProvideMapSource("test", map[string]string{"ENDPOINT_URL": endpoint})),
+			}
+			report, _ := Validate(provider, nil)
+			if entry := reportEntry(t, report, "ENDPOINT_URL"); (len(entry.Problem) == 0) != valid {
+				t.Fatalf("ENDPOINT_URL entry = %+v, want valid = %t", entry, valid)
+			}
+		})
+	}
+}
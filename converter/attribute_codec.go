@@ -0,0 +1,135 @@
+package converter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// MarshalAttributeValue encodes an attribute value in the DynamoDB JSON format, e.g. {"S":"value"}.
+// Map keys are sorted, so the same value always produces the same bytes.
+func MarshalAttributeValue(value types.AttributeValue) ([]byte, error) {
+	encoded, err := toDynamoJson(value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalAttributeValue decodes bytes produced by MarshalAttributeValue.
+func UnmarshalAttributeValue(data []byte) (types.AttributeValue, error) {
+	var encoded map[string]json.RawMessage
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, err
+	}
+	return fromDynamoJson(encoded)
+}
+
+func toDynamoJson(value types.AttributeValue) (map[string]any, error) {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		return map[string]any{"S": v.Value}, nil
+	case *types.AttributeValueMemberN:
+		return map[string]any{"N": v.Value}, nil
+	case *types.AttributeValueMemberB:
+		return map[string]any{"B": v.Value}, nil
+	case *types.AttributeValueMemberBOOL:
+		return map[string]any{"BOOL": v.Value}, nil
+	case *types.AttributeValueMemberNULL:
+		return map[string]any{"NULL": v.Value}, nil
+	case *types.AttributeValueMemberSS:
+		return map[string]any{"SS": v.Value}, nil
+	case *types.AttributeValueMemberNS:
+		return map[string]any{"NS": v.Value}, nil
+	case *types.AttributeValueMemberBS:
+		return map[string]any{"BS": v.Value}, nil
+	case *types.AttributeValueMemberL:
+		list := make([]any, 0, len(v.Value))
+		for _, member := range v.Value {
+			encoded, err := toDynamoJson(member)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, encoded)
+		}
+		return map[string]any{"L": list}, nil
+	case *types.AttributeValueMemberM:
+		members := make(map[string]any, len(v.Value))
+		for key, member := range v.Value {
+			encoded, err := toDynamoJson(member)
+			if err != nil {
+				return nil, err
+			}
+			members[key] = encoded
+		}
+		return map[string]any{"M": members}, nil
+	default:
+		return nil, fmt.Errorf("unsupported attribute value: %T", value)
+	}
+}
+
+func fromDynamoJson(encoded map[string]json.RawMessage) (types.AttributeValue, error) {
+	if len(encoded) != 1 {
+		return nil, errors.New("attribute value must have exactly one type")
+	}
+	for kind, raw := range encoded {
+		switch kind {
+		case "S":
+			value := &types.AttributeValueMemberS{}
+			return value, json.Unmarshal(raw, &value.Value)
+		case "N":
+			value := &types.AttributeValueMemberN{}
+			return value, json.Unmarshal(raw, &value.Value)
+		case "B":
+			value := &types.AttributeValueMemberB{}
+			return value, json.Unmarshal(raw, &value.Value)
+		case "BOOL":
+			value := &types.AttributeValueMemberBOOL{}
+			return value, json.Unmarshal(raw, &value.Value)
+		case "NULL":
+			value := &types.AttributeValueMemberNULL{}
+			return value, json.Unmarshal(raw, &value.Value)
+		case "SS":
+			value := &types.AttributeValueMemberSS{}
+			return value, json.Unmarshal(raw, &value.Value)
+		case "NS":
+			value := &types.AttributeValueMemberNS{}
+			return value, json.Unmarshal(raw, &value.Value)
+		case "BS":
+			value := &types.AttributeValueMemberBS{}
+			return value, json.Unmarshal(raw, &value.Value)
+		case "L":
+			var list []map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &list); err != nil {
+				return nil, err
+			}
+			value := &types.AttributeValueMemberL{Value: make([]types.AttributeValue, 0, len(list))}
+			for _, member := range list {
+				decoded, err := fromDynamoJson(member)
+				if err != nil {
+					return nil, err
+				}
+				value.Value = append(value.Value, decoded)
+			}
+			return value, nil
+		case "M":
+			var members map[string]map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &members); err != nil {
+				return nil, err
+			}
+			value := &types.AttributeValueMemberM{Value: make(map[string]types.AttributeValue, len(members))}
+			for key, member := range members {
+				decoded, err := fromDynamoJson(member)
+				if err != nil {
+					return nil, err
+				}
+				value.Value[key] = decoded
+			}
+			return value, nil
+		default:
+			return nil, fmt.Errorf("unsupported attribute type: %s", kind)
+		}
+	}
+	return nil, errors.New("attribute value must have exactly one type")
+}
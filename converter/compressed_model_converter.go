@@ -0,0 +1,172 @@
+package converter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/klauspost/compress/zstd"
+	"github.com/nicholaspark09/awsgorocket/metrics"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Codec is the byte after compressionMagic that names the compression of an attribute.
+type Codec byte
+
+const (
+	Gzip Codec = 1
+	Zstd Codec = 2
+)
+
+// compressionMagic starts every compressed attribute. 0x00 0xFF can't start UTF-8 text and isn't the
+// signature of a common binary format, so an existing B value is not mistaken for a compressed one.
+var compressionMagic = []byte{0x00, 0xFF, 'a', 'g', 'r'}
+
+// The zstd encoder and decoder are safe for concurrent EncodeAll and DecodeAll calls, and each one
+// keeps goroutines and buffers, so they are shared.
+var (
+	zstdEncoder = sync.OnceValues(func() (*zstd.Encoder, error) {
+		return zstd.NewWriter(nil)
+	})
+	zstdDecoder = sync.OnceValues(func() (*zstd.Decoder, error) {
+		return zstd.NewReader(nil)
+	})
+)
+
+// CompressedModelConverter wraps another converter and stores the configured attributes as B values of
+// compressionMagic, a codec byte and the compressed attribute. Values without that header are passed
+// through untouched on read, so existing items stay readable.
+type CompressedModelConverter[T any] struct {
+	converter      ModelConverterContract[T]
+	codecs         map[string]Codec
+	metricsManager metrics.MetricsManagerContract
+}
+
+// ProvideCompressedModelConverter takes an optional metricsManager that receives the compression ratio of every write.
+func ProvideCompressedModelConverter[T any](
+	converter ModelConverterContract[T],
+	codecs map[string]Codec,
+	metricsManager metrics.MetricsManagerContract,
+) ModelConverterContract[T] {
+	return &CompressedModelConverter[T]{
+		converter:      converter,
+		codecs:         codecs,
+		metricsManager: metricsManager,
+	}
+}
+
+func (compressed *CompressedModelConverter[T]) ConvertToItem(data *T) (map[string]types.AttributeValue, *error) {
+	item, convertError := compressed.converter.ConvertToItem(data)
+	if convertError != nil {
+		return nil, convertError
+	}
+	for name, codec := range compressed.codecs {
+		value, ok := item[name]
+		if !ok {
+			continue
+		}
+		encoded, err := MarshalAttributeValue(value)
+		if err != nil {
+			return nil, &err
+		}
+		packed, err := compress(codec, encoded)
+		if err != nil {
+			currentLogger().Error("Error in compressing attribute", "attribute", name, "error", err)
+			return nil, &err
+		}
+		if compressed.metricsManager != nil && len(packed) > 0 {
+			metrics.Histogram(compressed.metricsManager, "CompressionRatio", float64(len(encoded))/float64(len(packed)),
+				metrics.UnitNone, metrics.Dim("Attribute", name))
+		}
+		item[name] = &types.AttributeValueMemberB{Value: packed}
+	}
+	return item, nil
+}
+
+func (compressed *CompressedModelConverter[T]) ConvertToModel(item map[string]types.AttributeValue) (*T, *error) {
+	decompressed := make(map[string]types.AttributeValue, len(item))
+	for key, value := range item {
+		decompressed[key] = value
+	}
+	for name := range compressed.codecs {
+		packed, ok := decompressed[name].(*types.AttributeValueMemberB)
+		if !ok || len(packed.Value) == 0 {
+			continue
+		}
+		encoded, err := decompress(packed.Value)
+		if err != nil {
+			currentLogger().Error("Error in decompressing attribute", "attribute", name, "error", err)
+			return nil, &err
+		}
+		if encoded == nil {
+			continue
+		}
+		value, err := UnmarshalAttributeValue(encoded)
+		if err != nil {
+			return nil, &err
+		}
+		decompressed[name] = value
+	}
+	return compressed.converter.ConvertToModel(decompressed)
+}
+
+func (compressed *CompressedModelConverter[T]) TransformedAttributes() []string {
+	names := make([]string, 0, len(compressed.codecs))
+	for name := range compressed.codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append(names, TransformedAttributes(compressed.converter)...)
+}
+
+func compress(codec Codec, data []byte) ([]byte, error) {
+	header := append(append(make([]byte, 0, len(compressionMagic)+1), compressionMagic...), byte(codec))
+	switch codec {
+	case Gzip:
+		buffer := bytes.NewBuffer(header)
+		writer := gzip.NewWriter(buffer)
+		if _, err := writer.Write(data); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
+	case Zstd:
+		encoder, err := zstdEncoder()
+		if err != nil {
+			return nil, err
+		}
+		return encoder.EncodeAll(data, header), nil
+	default:
+		return nil, fmt.Errorf("unknown codec: %d", codec)
+	}
+}
+
+// decompress returns nil without an error when the value does not start with compressionMagic.
+func decompress(packed []byte) ([]byte, error) {
+	if len(packed) <= len(compressionMagic) || !bytes.HasPrefix(packed, compressionMagic) {
+		return nil, nil
+	}
+	codec := Codec(packed[len(compressionMagic)])
+	body := packed[len(compressionMagic)+1:]
+	switch codec {
+	case Gzip:
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	case Zstd:
+		decoder, err := zstdDecoder()
+		if err != nil {
+			return nil, err
+		}
+		return decoder.DecodeAll(body, nil)
+	default:
+		return nil, fmt.Errorf("unknown codec: %d", codec)
+	}
+}
@@ -0,0 +1,131 @@
+package converter
+
+import (
+	"bytes"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nicholaspark09/awsgorocket/metrics"
+	"strings"
+	"testing"
+	"time"
+)
+
+type article struct {
+	PartitionKey string
+	Body         string
+	Attachment   []byte
+}
+
+type articleConverter struct{}
+
+func (articleConverter) ConvertToItem(data *article) (map[string]types.AttributeValue, *error) {
+	return map[string]types.AttributeValue{
+		PartitionKeyAttribute: &types.AttributeValueMemberS{Value: data.PartitionKey},
+		"body":                &types.AttributeValueMemberS{Value: data.Body},
+		"attachment":          &types.AttributeValueMemberB{Value: data.Attachment},
+	}, nil
+}
+
+func (articleConverter) ConvertToModel(item map[string]types.AttributeValue) (*article, *error) {
+	model := &article{PartitionKey: ToString(PartitionKeyAttribute, item), Body: ToString("body", item)}
+	if attachment, ok := item["attachment"].(*types.AttributeValueMemberB); ok {
+		model.Attachment = attachment.Value
+	}
+	return model, nil
+}
+
+type histogram struct {
+	name       string
+	value      float64
+	dimensions []metrics.Dimension
+}
+
+// histogramManager keeps the Histogram calls and ignores everything else.
+type histogramManager struct {
+	histograms []histogram
+}
+
+func (manager *histogramManager) SendMeasuredTime(callName string, time time.Duration)         {}
+func (manager *histogramManager) SendLog(tag string, message string)                           {}
+func (manager *histogramManager) Send500Error(callName string, statusCode int, message string) {}
+func (manager *histogramManager) Send400Error(callName string, statusCode int, message string) {}
+func (manager *histogramManager) Counter(name string, value float64, dimensions ...metrics.Dimension) {
+}
+func (manager *histogramManager) Gauge(name string, value float64, unit metrics.Unit, dimensions ...metrics.Dimension) {
+}
+func (manager *histogramManager) Timer(name string, duration time.Duration, dimensions ...metrics.Dimension) {
+}
+func (manager *histogramManager) Histogram(name string, value float64, unit metrics.Unit, dimensions ...metrics.Dimension) {
+	manager.histograms = append(manager.histograms, histogram{name: name, value: value, dimensions: dimensions})
+}
+
+func sampleArticle() *article {
+	return &article{PartitionKey: "article#1", Body: strings.Repeat("compress me ", 200), Attachment: []byte{0x01, 0x02, 0x03}}
+}
+
+func TestCompressedModelConverterRoundTrip(t *testing.T) {
+	for _, codec := range []Codec{Gzip, Zstd} {
+		converter := ProvideCompressedModelConverter[article](articleConverter{}, map[string]Codec{"body": codec}, nil)
+		item, convertError := converter.ConvertToItem(sampleArticle())
+		if convertError != nil {
+			t.Fatalf("codec %d: ConvertToItem: %v", codec, *convertError)
+		}
+		packed, ok := item["body"].(*types.AttributeValueMemberB)
+		if !ok || !bytes.HasPrefix(packed.Value, append(compressionMagic, byte(codec))) {
+			t.Fatalf("codec %d: body = %#v, want a B value with the compression header", codec, item["body"])
+		}
+		if len(packed.Value) >= len(sampleArticle().Body) {
+			t.Fatalf("codec %d: compressed body is %d bytes, want fewer than %d", codec, len(packed.Value), len(sampleArticle().Body))
+		}
+		model, convertError := converter.ConvertToModel(item)
+		if convertError != nil {
+			t.Fatalf("codec %d: ConvertToModel: %v", codec, *convertError)
+		}
+		if model.Body != sampleArticle().Body {
+			t.Fatalf("codec %d: body did not survive the round trip", codec)
+		}
+	}
+}
+
+func TestCompressedModelConverterPassesLegacyValuesThrough(t *testing.T) {
+	converter := ProvideCompressedModelConverter[article](articleConverter{}, map[string]Codec{"attachment": Gzip}, nil)
+	for _, legacy := range [][]byte{{0x01, 0x02, 0x03}, {0x02}, {0x00, 0xFF}, []byte("plain bytes")} {
+		model, convertError := converter.ConvertToModel(map[string]types.AttributeValue{
+			PartitionKeyAttribute: &types.AttributeValueMemberS{Value: "article#1"},
+			"attachment":          &types.AttributeValueMemberB{Value: legacy},
+		})
+		if convertError != nil {
+			t.Fatalf("legacy value %v: %v", legacy, *convertError)
+		}
+		if !bytes.Equal(model.Attachment, legacy) {
+			t.Fatalf("Attachment = %v, want the legacy value %v untouched", model.Attachment, legacy)
+		}
+	}
+}
+
+func TestCompressedModelConverterRejectsCorruptValues(t *testing.T) {
+	converter := ProvideCompressedModelConverter[article](articleConverter{}, map[string]Codec{"attachment": Zstd}, nil)
+	_, convertError := converter.ConvertToModel(map[string]types.AttributeValue{
+		"attachment": &types.AttributeValueMemberB{Value: append(append([]byte{}, compressionMagic...), byte(Zstd), 0x01, 0x02)},
+	})
+	if convertError == nil {
+		t.Fatal("expected an error for a value with the compression header that does not decompress")
+	}
+}
+
+func TestCompressedModelConverterRecordsCompressionRatio(t *testing.T) {
+	manager := &histogramManager{}
+	converter := ProvideCompressedModelConverter[article](articleConverter{}, map[string]Codec{"body": Zstd}, manager)
+	if _, convertError := converter.ConvertToItem(sampleArticle()); convertError != nil {
+		t.Fatal(*convertError)
+	}
+	if len(manager.histograms) != 1 {
+		t.Fatalf("histograms = %v, want one CompressionRatio", manager.histograms)
+	}
+	recorded := manager.histograms[0]
+	if recorded.name != "CompressionRatio" || recorded.value <= 1 {
+		t.Fatalf("histogram = %+v, want CompressionRatio above 1 for repetitive text", recorded)
+	}
+	if len(recorded.dimensions) != 1 || recorded.dimensions[0] != metrics.Dim("Attribute", "body") {
+		t.Fatalf("dimensions = %v, want Attribute=body", recorded.dimensions)
+	}
+}
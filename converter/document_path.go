@@ -0,0 +1,114 @@
+package converter
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"strconv"
+	"strings"
+)
+
+// PathElement is one step of a document path: a map key, or a list index when IsIndex is set.
+type PathElement struct {
+	Name    string
+	Index   int
+	IsIndex bool
+}
+
+// ParsePath splits a document path such as "address.city" or "orders[2].items[0].sku" into its elements.
+func ParsePath(path string) ([]PathElement, error) {
+	var elements []PathElement
+	for _, segment := range strings.Split(path, ".") {
+		name := segment
+		var indexes []int
+		if bracket := strings.IndexByte(segment, '['); bracket >= 0 {
+			name = segment[:bracket]
+			rest := segment[bracket:]
+			for len(rest) > 0 {
+				closing := strings.IndexByte(rest, ']')
+				if rest[0] != '[' || closing < 0 {
+					return nil, fmt.Errorf("malformed list index in path %q", path)
+				}
+				index, err := strconv.Atoi(rest[1:closing])
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("invalid list index in path %q", path)
+				}
+				indexes = append(indexes, index)
+				rest = rest[closing+1:]
+			}
+		}
+		if len(name) == 0 {
+			return nil, fmt.Errorf("empty attribute name in path %q", path)
+		}
+		elements = append(elements, PathElement{Name: name})
+		for _, index := range indexes {
+			elements = append(elements, PathElement{Index: index, IsIndex: true})
+		}
+	}
+	return elements, nil
+}
+
+// GetPath walks nested M and L attributes and returns the value at path, or nil when it does not exist.
+func GetPath(item map[string]types.AttributeValue, path string) types.AttributeValue {
+	elements, err := ParsePath(path)
+	if err != nil {
+		return nil
+	}
+	var current types.AttributeValue = &types.AttributeValueMemberM{Value: item}
+	for _, element := range elements {
+		switch v := current.(type) {
+		case *types.AttributeValueMemberM:
+			if element.IsIndex {
+				return nil
+			}
+			next, ok := v.Value[element.Name]
+			if !ok {
+				return nil
+			}
+			current = next
+		case *types.AttributeValueMemberL:
+			if !element.IsIndex || element.Index >= len(v.Value) {
+				return nil
+			}
+			current = v.Value[element.Index]
+		default:
+			return nil
+		}
+	}
+	return current
+}
+
+// ExpressionPath turns a document path into an expression with name placeholders, e.g. "#n0.#n1[0]",
+// adding the placeholders to names. Names already in the map reuse their placeholder.
+func ExpressionPath(path string, names map[string]string) (string, error) {
+	elements, err := ParsePath(path)
+	if err != nil {
+		return "", err
+	}
+	var builder strings.Builder
+	for i, element := range elements {
+		if element.IsIndex {
+			builder.WriteString(fmt.Sprintf("[%d]", element.Index))
+			continue
+		}
+		if i > 0 {
+			builder.WriteByte('.')
+		}
+		builder.WriteString(namePlaceholder(element.Name, names))
+	}
+	return builder.String(), nil
+}
+
+func namePlaceholder(name string, names map[string]string) string {
+	for placeholder, existing := range names {
+		if existing == name {
+			return placeholder
+		}
+	}
+	for i := len(names); ; i++ {
+		placeholder := fmt.Sprintf("#n%d", i)
+		if _, taken := names[placeholder]; !taken {
+			names[placeholder] = name
+			return placeholder
+		}
+	}
+}
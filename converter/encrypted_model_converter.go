@@ -0,0 +1,257 @@
+package converter
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"sort"
+)
+
+type AttributeAction int
+
+const (
+	// Encrypt replaces the attribute with its ciphertext.
+	Encrypt AttributeAction = iota
+	// EncryptAndSign encrypts the attribute and covers the ciphertext with the item signature.
+	EncryptAndSign
+	// SignOnly leaves the attribute readable but covers it with the item signature.
+	SignOnly
+)
+
+const (
+	MaterialAttribute  = "__encryption_material"
+	SignatureAttribute = "__signature"
+
+	PartitionKeyAttribute = "partition_key"
+	RangeKeyAttribute     = "range_key"
+
+	encryptedValueVersion byte = 1
+)
+
+// EncryptedModelConverter wraps another converter and encrypts the configured attributes with AES-GCM
+// using a data key from the KeyProvider. A fresh data key is generated for every item and stored,
+// encrypted, in MaterialAttribute.
+type EncryptedModelConverter[T any] struct {
+	converter   ModelConverterContract[T]
+	keyProvider KeyProvider
+	actions     map[string]AttributeAction
+	options     EncryptedConverterOptions
+}
+
+type EncryptedConverterOptions struct {
+	// AllowPlaintextItems lets ConvertToModel read items written before encryption was turned on, i.e. items
+	// without MaterialAttribute. They are passed to the wrapped converter as-is and get encrypted on their
+	// next write.
+	AllowPlaintextItems bool
+}
+
+func ProvideEncryptedModelConverter[T any](
+	converter ModelConverterContract[T],
+	keyProvider KeyProvider,
+	actions map[string]AttributeAction,
+) ModelConverterContract[T] {
+	return ProvideEncryptedModelConverterWithOptions(converter, keyProvider, actions, EncryptedConverterOptions{})
+}
+
+func ProvideEncryptedModelConverterWithOptions[T any](
+	converter ModelConverterContract[T],
+	keyProvider KeyProvider,
+	actions map[string]AttributeAction,
+	options EncryptedConverterOptions,
+) ModelConverterContract[T] {
+	return &EncryptedModelConverter[T]{
+		converter:   converter,
+		keyProvider: keyProvider,
+		actions:     actions,
+		options:     options,
+	}
+}
+
+func (encrypted *EncryptedModelConverter[T]) ConvertToItem(data *T) (map[string]types.AttributeValue, *error) {
+	item, convertError := encrypted.converter.ConvertToItem(data)
+	if convertError != nil {
+		return nil, convertError
+	}
+	plaintextKey, encryptedKey, keyId, err := encrypted.keyProvider.GenerateDataKey(context.TODO())
+	if err != nil {
+		currentLogger().Error("Error in generating a data key", "error", err)
+		return nil, &err
+	}
+	for _, name := range encrypted.attributeNames() {
+		action := encrypted.actions[name]
+		value, ok := item[name]
+		if !ok || action == SignOnly {
+			continue
+		}
+		ciphertext, err := encryptAttribute(plaintextKey, name, value)
+		if err != nil {
+			currentLogger().Error("Error in encrypting attribute", "attribute", name, "error", err)
+			return nil, &err
+		}
+		item[name] = &types.AttributeValueMemberB{Value: ciphertext}
+	}
+	item[MaterialAttribute] = &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+		"key_id":   &types.AttributeValueMemberS{Value: keyId},
+		"data_key": &types.AttributeValueMemberB{Value: encryptedKey},
+	}}
+	if encrypted.signs() {
+		signature, err := encrypted.sign(plaintextKey, item)
+		if err != nil {
+			return nil, &err
+		}
+		item[SignatureAttribute] = &types.AttributeValueMemberB{Value: signature}
+	}
+	return item, nil
+}
+
+func (encrypted *EncryptedModelConverter[T]) ConvertToModel(item map[string]types.AttributeValue) (*T, *error) {
+	if _, found := item[MaterialAttribute]; !found && encrypted.options.AllowPlaintextItems {
+		currentLogger().Debug("Reading an item without encryption material as plaintext")
+		return encrypted.converter.ConvertToModel(item)
+	}
+	material, ok := item[MaterialAttribute].(*types.AttributeValueMemberM)
+	if !ok {
+		err := errors.New("item is missing its encryption material")
+		return nil, &err
+	}
+	keyId := ToString("key_id", material.Value)
+	encryptedKey, ok := material.Value["data_key"].(*types.AttributeValueMemberB)
+	if !ok {
+		err := errors.New("encryption material is missing its data key")
+		return nil, &err
+	}
+	plaintextKey, err := encrypted.keyProvider.DecryptDataKey(context.TODO(), encryptedKey.Value, keyId)
+	if err != nil {
+		currentLogger().Error("Error in decrypting the data key", "error", err)
+		return nil, &err
+	}
+	if encrypted.signs() {
+		if err := encrypted.verify(plaintextKey, item); err != nil {
+			return nil, &err
+		}
+	}
+	decrypted := make(map[string]types.AttributeValue, len(item))
+	for key, value := range item {
+		if key == MaterialAttribute || key == SignatureAttribute {
+			continue
+		}
+		decrypted[key] = value
+	}
+	for _, name := range encrypted.attributeNames() {
+		value, ok := decrypted[name]
+		if !ok || encrypted.actions[name] == SignOnly {
+			continue
+		}
+		ciphertext, ok := value.(*types.AttributeValueMemberB)
+		if !ok {
+			err := fmt.Errorf("encrypted attribute %s is not binary", name)
+			return nil, &err
+		}
+		plaintext, err := decryptAttribute(plaintextKey, name, ciphertext.Value)
+		if err != nil {
+			currentLogger().Error("Error in decrypting attribute", "attribute", name, "error", err)
+			return nil, &err
+		}
+		decrypted[name] = plaintext
+	}
+	return encrypted.converter.ConvertToModel(decrypted)
+}
+
+// TransformedAttributes includes the sign-only attributes, since changing them breaks the signature.
+func (encrypted *EncryptedModelConverter[T]) TransformedAttributes() []string {
+	names := append(encrypted.attributeNames(), MaterialAttribute, SignatureAttribute)
+	return append(names, TransformedAttributes(encrypted.converter)...)
+}
+
+func (encrypted *EncryptedModelConverter[T]) attributeNames() []string {
+	names := make([]string, 0, len(encrypted.actions))
+	for name := range encrypted.actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (encrypted *EncryptedModelConverter[T]) signs() bool {
+	for _, action := range encrypted.actions {
+		if action != Encrypt {
+			return true
+		}
+	}
+	return false
+}
+
+// sign computes an HMAC over the primary key, the encryption material and the signed attributes, so a
+// signed item can't be copied under another key.
+func (encrypted *EncryptedModelConverter[T]) sign(dataKey []byte, item map[string]types.AttributeValue) ([]byte, error) {
+	mac := hmac.New(sha256.New, deriveKey(dataKey, "signing"))
+	names := []string{PartitionKeyAttribute, RangeKeyAttribute, MaterialAttribute}
+	for _, name := range encrypted.attributeNames() {
+		if encrypted.actions[name] != Encrypt {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		value, ok := item[name]
+		if !ok {
+			continue
+		}
+		encoded, err := MarshalAttributeValue(value)
+		if err != nil {
+			return nil, err
+		}
+		mac.Write([]byte(name))
+		mac.Write([]byte{0})
+		mac.Write(encoded)
+		mac.Write([]byte{0})
+	}
+	return mac.Sum(nil), nil
+}
+
+func (encrypted *EncryptedModelConverter[T]) verify(dataKey []byte, item map[string]types.AttributeValue) error {
+	signature, ok := item[SignatureAttribute].(*types.AttributeValueMemberB)
+	if !ok {
+		return errors.New("item is missing its signature")
+	}
+	expected, err := encrypted.sign(dataKey, item)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(signature.Value, expected) {
+		return errors.New("item signature does not match")
+	}
+	return nil
+}
+
+func encryptAttribute(dataKey []byte, name string, value types.AttributeValue) ([]byte, error) {
+	plaintext, err := MarshalAttributeValue(value)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := sealWithKey(deriveKey(dataKey, "encryption"), plaintext, []byte(name))
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{encryptedValueVersion}, sealed...), nil
+}
+
+func decryptAttribute(dataKey []byte, name string, ciphertext []byte) (types.AttributeValue, error) {
+	if len(ciphertext) == 0 || ciphertext[0] != encryptedValueVersion {
+		return nil, errors.New("unknown encrypted value version")
+	}
+	plaintext, err := openWithKey(deriveKey(dataKey, "encryption"), ciphertext[1:], []byte(name))
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalAttributeValue(plaintext)
+}
+
+// deriveKey splits the data key into separate keys for encryption and signing.
+func deriveKey(dataKey []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, dataKey)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
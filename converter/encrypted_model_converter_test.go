@@ -0,0 +1,173 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"strconv"
+	"testing"
+)
+
+type account struct {
+	PartitionKey string
+	RangeKey     string
+	Owner        string
+	Balance      int
+}
+
+type accountConverter struct{}
+
+func (accountConverter) ConvertToItem(data *account) (map[string]types.AttributeValue, *error) {
+	return map[string]types.AttributeValue{
+		PartitionKeyAttribute: &types.AttributeValueMemberS{Value: data.PartitionKey},
+		RangeKeyAttribute:     &types.AttributeValueMemberS{Value: data.RangeKey},
+		"owner":               &types.AttributeValueMemberS{Value: data.Owner},
+		"balance":             &types.AttributeValueMemberN{Value: strconv.Itoa(data.Balance)},
+	}, nil
+}
+
+func (accountConverter) ConvertToModel(item map[string]types.AttributeValue) (*account, *error) {
+	return &account{
+		PartitionKey: ToString(PartitionKeyAttribute, item),
+		RangeKey:     ToString(RangeKeyAttribute, item),
+		Owner:        ToString("owner", item),
+		Balance:      ToInt("balance", item),
+	}, nil
+}
+
+func newStaticKeyProvider(t *testing.T, keyId string, fill byte) *StaticKeyProvider {
+	t.Helper()
+	provider, err := ProvideStaticKeyProvider(keyId, bytes.Repeat([]byte{fill}, dataKeySize))
+	if err != nil {
+		t.Fatalf("ProvideStaticKeyProvider: %v", err)
+	}
+	return provider
+}
+
+func newAccountConverter(keyProvider KeyProvider) ModelConverterContract[account] {
+	return ProvideEncryptedModelConverter[account](accountConverter{}, keyProvider, map[string]AttributeAction{
+		"owner":   EncryptAndSign,
+		"balance": SignOnly,
+	})
+}
+
+func sampleAccount() *account {
+	return &account{PartitionKey: "customer#1", RangeKey: "account#1", Owner: "Jordan", Balance: 42}
+}
+
+func TestStaticKeyProviderRejectsShortMasterKey(t *testing.T) {
+	if _, err := ProvideStaticKeyProvider("local", []byte("too short")); err == nil {
+		t.Fatal("expected an error for a master key that is not 32 bytes")
+	}
+}
+
+func TestStaticKeyProviderRoundTrip(t *testing.T) {
+	provider := newStaticKeyProvider(t, "local", 1)
+	plaintextKey, encryptedKey, keyId, err := provider.GenerateDataKey(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateDataKey: %v", err)
+	}
+	if bytes.Equal(plaintextKey, encryptedKey) {
+		t.Fatal("encrypted data key equals the plaintext key")
+	}
+	decrypted, err := provider.DecryptDataKey(context.Background(), encryptedKey, keyId)
+	if err != nil {
+		t.Fatalf("DecryptDataKey: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintextKey) {
+		t.Fatal("decrypted data key does not match the generated one")
+	}
+}
+
+func TestEncryptedConverterRoundTrip(t *testing.T) {
+	encrypted := newAccountConverter(newStaticKeyProvider(t, "local", 1))
+	item, err := encrypted.ConvertToItem(sampleAccount())
+	if err != nil {
+		t.Fatalf("ConvertToItem: %v", *err)
+	}
+	if _, ok := item["owner"].(*types.AttributeValueMemberB); !ok {
+		t.Fatalf("owner was not encrypted: %#v", item["owner"])
+	}
+	if balance, ok := item["balance"].(*types.AttributeValueMemberN); !ok || balance.Value != "42" {
+		t.Fatalf("sign-only balance was changed: %#v", item["balance"])
+	}
+	model, err := encrypted.ConvertToModel(item)
+	if err != nil {
+		t.Fatalf("ConvertToModel: %v", *err)
+	}
+	if *model != *sampleAccount() {
+		t.Fatalf("round trip = %+v, want %+v", *model, *sampleAccount())
+	}
+}
+
+func TestEncryptedConverterDetectsTampering(t *testing.T) {
+	tests := map[string]func(item map[string]types.AttributeValue){
+		"signed attribute": func(item map[string]types.AttributeValue) {
+			item["balance"] = &types.AttributeValueMemberN{Value: "1000000"}
+		},
+		"partition key": func(item map[string]types.AttributeValue) {
+			item[PartitionKeyAttribute] = &types.AttributeValueMemberS{Value: "customer#2"}
+		},
+		"range key": func(item map[string]types.AttributeValue) {
+			item[RangeKeyAttribute] = &types.AttributeValueMemberS{Value: "account#2"}
+		},
+		"ciphertext": func(item map[string]types.AttributeValue) {
+			ciphertext := item["owner"].(*types.AttributeValueMemberB).Value
+			ciphertext[len(ciphertext)-1] ^= 0xff
+		},
+		"missing signature": func(item map[string]types.AttributeValue) {
+			delete(item, SignatureAttribute)
+		},
+	}
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			encrypted := newAccountConverter(newStaticKeyProvider(t, "local", 1))
+			item, err := encrypted.ConvertToItem(sampleAccount())
+			if err != nil {
+				t.Fatalf("ConvertToItem: %v", *err)
+			}
+			tamper(item)
+			if _, err := encrypted.ConvertToModel(item); err == nil {
+				t.Fatal("expected the tampered item to be rejected")
+			}
+		})
+	}
+}
+
+func TestEncryptedConverterRejectsWrongKey(t *testing.T) {
+	item, err := newAccountConverter(newStaticKeyProvider(t, "local", 1)).ConvertToItem(sampleAccount())
+	if err != nil {
+		t.Fatalf("ConvertToItem: %v", *err)
+	}
+	tests := map[string]KeyProvider{
+		"different master key": newStaticKeyProvider(t, "local", 2),
+		"different key id":     newStaticKeyProvider(t, "other", 1),
+	}
+	for name, keyProvider := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := newAccountConverter(keyProvider).ConvertToModel(item); err == nil {
+				t.Fatal("expected the item to be unreadable with the wrong key")
+			}
+		})
+	}
+}
+
+func TestEncryptedConverterPlaintextItems(t *testing.T) {
+	keyProvider := newStaticKeyProvider(t, "local", 1)
+	plaintext, _ := accountConverter{}.ConvertToItem(sampleAccount())
+
+	if _, err := newAccountConverter(keyProvider).ConvertToModel(plaintext); err == nil {
+		t.Fatal("expected a plaintext item to be rejected by default")
+	}
+
+	migrating := ProvideEncryptedModelConverterWithOptions[account](accountConverter{}, keyProvider, map[string]AttributeAction{
+		"owner": EncryptAndSign,
+	}, EncryptedConverterOptions{AllowPlaintextItems: true})
+	model, err := migrating.ConvertToModel(plaintext)
+	if err != nil {
+		t.Fatalf("ConvertToModel: %v", *err)
+	}
+	if *model != *sampleAccount() {
+		t.Fatalf("plaintext read = %+v, want %+v", *model, *sampleAccount())
+	}
+}
@@ -0,0 +1,84 @@
+package converter
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"strings"
+)
+
+// MaxItemSize is the largest item DynamoDB accepts, in bytes.
+const MaxItemSize = 400 * 1024
+
+// ItemSize returns the size DynamoDB bills for an item: the sum of every attribute name and value.
+func ItemSize(item map[string]types.AttributeValue) int {
+	size := 0
+	for name, value := range item {
+		size += AttributeSize(name, value)
+	}
+	return size
+}
+
+// AttributeSize returns the billed size of one attribute, including its name.
+func AttributeSize(name string, value types.AttributeValue) int {
+	return len(name) + valueSize(value)
+}
+
+func valueSize(value types.AttributeValue) int {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		return len(v.Value)
+	case *types.AttributeValueMemberN:
+		return numberSize(v.Value)
+	case *types.AttributeValueMemberB:
+		return len(v.Value)
+	case *types.AttributeValueMemberBOOL, *types.AttributeValueMemberNULL:
+		return 1
+	case *types.AttributeValueMemberSS:
+		size := 0
+		for _, member := range v.Value {
+			size += len(member)
+		}
+		return size
+	case *types.AttributeValueMemberNS:
+		size := 0
+		for _, member := range v.Value {
+			size += numberSize(member)
+		}
+		return size
+	case *types.AttributeValueMemberBS:
+		size := 0
+		for _, member := range v.Value {
+			size += len(member)
+		}
+		return size
+	case *types.AttributeValueMemberL:
+		// Lists and maps cost 3 bytes plus 1 byte per element on top of their contents.
+		size := 3
+		for _, member := range v.Value {
+			size += valueSize(member) + 1
+		}
+		return size
+	case *types.AttributeValueMemberM:
+		size := 3
+		for key, member := range v.Value {
+			size += AttributeSize(key, member) + 1
+		}
+		return size
+	default:
+		return 0
+	}
+}
+
+// numberSize is (digits+1)/2 + 1 bytes for a number with that many significant digits. The sign, the
+// exponent, the decimal point and leading or trailing zeros don't count.
+func numberSize(number string) int {
+	digits := strings.TrimLeft(number, "+-")
+	if exponent := strings.IndexAny(digits, "eE"); exponent >= 0 {
+		digits = digits[:exponent]
+	}
+	digits = strings.Replace(digits, ".", "", 1)
+	digits = strings.Trim(digits, "0")
+	if len(digits) == 0 {
+		return 1
+	}
+	return (len(digits)+1)/2 + 1
+}
@@ -0,0 +1,62 @@
+package converter
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"testing"
+)
+
+func TestAttributeSize(t *testing.T) {
+	for _, test := range []struct {
+		description string
+		name        string
+		value       types.AttributeValue
+		want        int
+	}{
+		{"string", "shirtColor", &types.AttributeValueMemberS{Value: "R"}, 11},
+		{"string counts UTF-8 bytes", "c", &types.AttributeValueMemberS{Value: "é"}, 3},
+		{"zero", "n", &types.AttributeValueMemberN{Value: "0"}, 2},
+		{"three digits", "n", &types.AttributeValueMemberN{Value: "123"}, 4},
+		{"four digits", "n", &types.AttributeValueMemberN{Value: "1234"}, 4},
+		{"five digits", "n", &types.AttributeValueMemberN{Value: "12345"}, 5},
+		{"trailing zeros", "n", &types.AttributeValueMemberN{Value: "1000000"}, 3},
+		{"sign and leading zeros", "n", &types.AttributeValueMemberN{Value: "-0.00120"}, 3},
+		{"inner zeros", "n", &types.AttributeValueMemberN{Value: "10.01"}, 4},
+		{"exponent", "n", &types.AttributeValueMemberN{Value: "1.5E+10"}, 3},
+		{"38 digits", "n", &types.AttributeValueMemberN{Value: "12345678901234567890123456789012345678"}, 21},
+		{"binary", "b", &types.AttributeValueMemberB{Value: []byte{1, 2, 3, 4, 5}}, 6},
+		{"boolean", "ok", &types.AttributeValueMemberBOOL{Value: true}, 3},
+		{"null", "nothing", &types.AttributeValueMemberNULL{Value: true}, 8},
+		{"string set", "tags", &types.AttributeValueMemberSS{Value: []string{"a", "bc"}}, 7},
+		{"number set", "ns", &types.AttributeValueMemberNS{Value: []string{"1", "100"}}, 6},
+		{"binary set", "bs", &types.AttributeValueMemberBS{Value: [][]byte{{1}, {2, 3}}}, 5},
+		{"empty list", "l", &types.AttributeValueMemberL{}, 4},
+		{"list", "l", &types.AttributeValueMemberL{Value: []types.AttributeValue{
+			&types.AttributeValueMemberS{Value: "a"},
+			&types.AttributeValueMemberN{Value: "1"},
+		}}, 9},
+		{"empty map", "m", &types.AttributeValueMemberM{}, 4},
+		{"map", "m", &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"k": &types.AttributeValueMemberS{Value: "v"},
+		}}, 7},
+		{"map in a list", "l", &types.AttributeValueMemberL{Value: []types.AttributeValue{
+			&types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+				"a": &types.AttributeValueMemberBOOL{Value: true},
+			}},
+		}}, 11},
+	} {
+		if got := AttributeSize(test.name, test.value); got != test.want {
+			t.Errorf("%s: AttributeSize = %d, want %d", test.description, got, test.want)
+		}
+	}
+}
+
+func TestItemSize(t *testing.T) {
+	item := map[string]types.AttributeValue{
+		"partition_key": &types.AttributeValueMemberS{Value: "user#1"},
+		"age":           &types.AttributeValueMemberN{Value: "42"},
+		"active":        &types.AttributeValueMemberBOOL{Value: true},
+	}
+	if got := ItemSize(item); got != 19+5+7 {
+		t.Fatalf("ItemSize = %d, want %d", got, 19+5+7)
+	}
+}
@@ -0,0 +1,125 @@
+package converter
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/nicholaspark09/awsgorocket/config"
+)
+
+const dataKeySize = 32
+
+// KeyProvider hands out data keys for envelope encryption. The plaintext key encrypts the item
+// and the encrypted key is stored alongside it so it can be recovered on read.
+type KeyProvider interface {
+	GenerateDataKey(ctx context.Context) (plaintextKey []byte, encryptedKey []byte, keyId string, err error)
+	DecryptDataKey(ctx context.Context, encryptedKey []byte, keyId string) ([]byte, error)
+}
+
+// StaticKeyProvider wraps data keys with a fixed master key held in memory. Meant for tests and local development.
+type StaticKeyProvider struct {
+	keyId     string
+	masterKey []byte
+}
+
+func ProvideStaticKeyProvider(keyId string, masterKey []byte) (*StaticKeyProvider, error) {
+	if len(masterKey) != dataKeySize {
+		return nil, fmt.Errorf("master key must be %d bytes, got %d", dataKeySize, len(masterKey))
+	}
+	return &StaticKeyProvider{keyId: keyId, masterKey: masterKey}, nil
+}
+
+func (provider *StaticKeyProvider) GenerateDataKey(ctx context.Context) ([]byte, []byte, string, error) {
+	plaintextKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(plaintextKey); err != nil {
+		return nil, nil, "", err
+	}
+	encryptedKey, err := sealWithKey(provider.masterKey, plaintextKey, []byte(provider.keyId))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return plaintextKey, encryptedKey, provider.keyId, nil
+}
+
+func (provider *StaticKeyProvider) DecryptDataKey(ctx context.Context, encryptedKey []byte, keyId string) ([]byte, error) {
+	if keyId != provider.keyId {
+		return nil, fmt.Errorf("unknown key id: %s", keyId)
+	}
+	return openWithKey(provider.masterKey, encryptedKey, []byte(keyId))
+}
+
+// KmsKeyProvider generates and decrypts data keys with an AWS KMS key.
+type KmsKeyProvider struct {
+	client *kms.Client
+	keyId  string
+}
+
+func ProvideKmsKeyProvider(provider config.ConfigProvider, keyId string) *KmsKeyProvider {
+	return &KmsKeyProvider{
+		client: kms.NewFromConfig(provider.SdkConfig, func(options *kms.Options) {
+			options.BaseEndpoint = provider.Environment.BaseEndpoint(config.ServiceKms)
+		}),
+		keyId: keyId,
+	}
+}
+
+func (provider *KmsKeyProvider) GenerateDataKey(ctx context.Context) ([]byte, []byte, string, error) {
+	output, err := provider.client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   aws.String(provider.keyId),
+		KeySpec: kmstypes.DataKeySpecAes256,
+	})
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return output.Plaintext, output.CiphertextBlob, aws.ToString(output.KeyId), nil
+}
+
+func (provider *KmsKeyProvider) DecryptDataKey(ctx context.Context, encryptedKey []byte, keyId string) ([]byte, error) {
+	output, err := provider.client.Decrypt(ctx, &kms.DecryptInput{
+		CiphertextBlob: encryptedKey,
+		KeyId:          aws.String(keyId),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.Plaintext, nil
+}
+
+// sealWithKey encrypts with AES-GCM and prefixes the random nonce to the ciphertext.
+func sealWithKey(key []byte, plaintext []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGcm(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func openWithKey(key []byte, sealed []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGcm(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newGcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
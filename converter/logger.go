@@ -0,0 +1,19 @@
+package converter
+
+import (
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"log/slog"
+	"sync/atomic"
+)
+
+var packageLogger atomic.Pointer[slog.Logger]
+
+// SetLogger sets the logger for the converters and the To* helpers, which log nothing by default.
+// "Key not found" is logged at debug level.
+func SetLogger(logger *slog.Logger) {
+	packageLogger.Store(logger)
+}
+
+func currentLogger() *slog.Logger {
+	return utils.LoggerOrNop(packageLogger.Load())
+}
@@ -2,7 +2,6 @@ package converter
 
 import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
-	"log"
 	"strconv"
 )
 
@@ -11,6 +10,21 @@ type ModelConverterContract[T any] interface {
 	ConvertToModel(map[string]types.AttributeValue) (*T, *error)
 }
 
+// AttributeTransformer is implemented by converters that store some attributes in a form only they can
+// read back, such as ciphertext or compressed bytes. Document path operations must not touch those.
+type AttributeTransformer interface {
+	TransformedAttributes() []string
+}
+
+// TransformedAttributes returns the attributes transformed by modelConverter, including the ones of the
+// converters it wraps, or nil when it is not an AttributeTransformer.
+func TransformedAttributes(modelConverter any) []string {
+	if transformer, ok := modelConverter.(AttributeTransformer); ok {
+		return transformer.TransformedAttributes()
+	}
+	return nil
+}
+
 func ToUnsafeString(key string, item map[string]types.AttributeValue) *string {
 	value, ok := item[key]
 	if ok {
@@ -18,11 +32,11 @@ func ToUnsafeString(key string, item map[string]types.AttributeValue) *string {
 		case *types.AttributeValueMemberS:
 			return &v.Value
 		default:
-			log.Printf("Could not convert: %s", value)
+			currentLogger().Warn("Could not convert attribute", "key", key, "value", value)
 			return nil
 		}
 	}
-	log.Printf("Key not found: %s", key)
+	currentLogger().Debug("Key not found", "key", key)
 	return nil
 }
 
@@ -33,11 +47,11 @@ func ToString(key string, item map[string]types.AttributeValue) string {
 		case *types.AttributeValueMemberS:
 			return v.Value
 		default:
-			log.Printf("Could not convert: %s", value)
+			currentLogger().Warn("Could not convert attribute", "key", key, "value", value)
 			return ""
 		}
 	}
-	log.Printf("Key not found: %s", key)
+	currentLogger().Debug("Key not found", "key", key)
 	return ""
 }
 
@@ -48,23 +62,23 @@ func ToInt(key string, item map[string]types.AttributeValue) int {
 		case *types.AttributeValueMemberN:
 			num, err := strconv.Atoi(v.Value)
 			if err != nil {
-				log.Printf("Could not convert: %s", v.Value)
+				currentLogger().Warn("Could not convert attribute", "key", key, "value", v.Value)
 				return -1
 			}
 			return num
 		case *types.AttributeValueMemberS:
 			num, err := strconv.Atoi(v.Value)
 			if err != nil {
-				log.Printf("Could not convert: %s", v.Value)
+				currentLogger().Warn("Could not convert attribute", "key", key, "value", v.Value)
 				return -1
 			}
 			return num
 		default:
-			log.Printf("Could not convert: %s", value)
+			currentLogger().Warn("Could not convert attribute", "key", key, "value", value)
 			return -1
 		}
 	}
-	log.Printf("Key not found: %s", key)
+	currentLogger().Debug("Key not found", "key", key)
 	return -1
 }
 
@@ -75,10 +89,10 @@ func ToBool(key string, item map[string]types.AttributeValue) bool {
 		case *types.AttributeValueMemberBOOL:
 			return v.Value
 		default:
-			log.Printf("Could not convert: %s", value)
+			currentLogger().Warn("Could not convert attribute", "key", key, "value", value)
 			return false
 		}
 	}
-	log.Printf("Key not found: %s", key)
+	currentLogger().Debug("Key not found", "key", key)
 	return false
 }
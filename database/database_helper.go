@@ -3,130 +3,320 @@ package database
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/nicholaspark09/awsgorocket/converter"
-	"log"
+	"github.com/nicholaspark09/awsgorocket/metrics"
+	"github.com/nicholaspark09/awsgorocket/tracing"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
 )
 
+var ErrItemTooLarge = errors.New("item exceeds the DynamoDB item size limit")
+
 type DatabaseHelperContract[T any] interface {
-	Create(data T) (*T, *error)
+	Create(data *T) (*T, *error)
 	Fetch(partitionKey string, rangeKey string) (*T, *error)
 	FetchAll(partitionKey string, limit int32, lastRangeKey *string) ([]*T, *string)
 	Update(data T) bool
 	Delete(partitionKey string, rangeKey string) bool
 }
 
+// DatabaseHelperContextContract is DatabaseHelperContract with a context for cancellation and tracing
+// and plain errors. Fetch returns nil and no error when the item does not exist.
+type DatabaseHelperContextContract[T any] interface {
+	CreateWithContext(ctx context.Context, data *T) (*T, error)
+	FetchWithContext(ctx context.Context, partitionKey string, rangeKey string) (*T, error)
+	FetchAllWithContext(ctx context.Context, partitionKey string, limit int32, lastRangeKey *string) ([]*T, *string, error)
+	UpdateWithContext(ctx context.Context, data T) error
+	DeleteWithContext(ctx context.Context, partitionKey string, rangeKey string) error
+}
+
 type DatabaseHelper[T any] struct {
 	Client    *dynamodb.Client
 	TableName *string
 	Converter converter.ModelConverterContract[T]
+	// Offloader is optional; when set, oversized attributes are kept in its BlobStore instead of the item.
+	Offloader *LargeAttributeOffloader
+	// MetricsManager is optional; when set, the consumed capacity of every call is reported to it.
+	MetricsManager metrics.MetricsManagerContract
+	// Logger is optional; nothing is logged without one.
+	Logger *slog.Logger
+	// TracerProvider is optional; without one spans go to the global provider, which drops them until
+	// the application installs one with otel.SetTracerProvider.
+	TracerProvider trace.TracerProvider
 }
 
 func (helper *DatabaseHelper[T]) Create(data *T) (*T, *error) {
+	result, err := helper.CreateWithContext(context.Background(), data)
+	return result, errorPointer(err)
+}
+
+// CreateWithContext writes data; the call is cancelled with ctx and its span is a child of the span in ctx.
+func (helper *DatabaseHelper[T]) CreateWithContext(ctx context.Context, data *T) (*T, error) {
+	ctx, span := helper.startSpan(ctx, "Create")
+	defer span.End()
 	item, err := helper.Converter.ConvertToItem(data)
 	if err != nil {
-		log.Printf("Error in converting object: %s", errors.Unwrap(*err).Error())
-		return nil, err
-	}
-	itemInput := &dynamodb.PutItemInput{
-		TableName: helper.TableName,
-		Item:      item,
+		helper.logger().Error("Error in converting object", "error", *err)
+		tracing.RecordError(span, *err)
+		return nil, *err
 	}
-	_, clientError := helper.Client.PutItem(context.TODO(), itemInput)
-	if clientError != nil {
-		log.Printf("Error in creating an item: %s", clientError.Error())
-		return nil, &clientError
+	if putError := helper.putItem(ctx, "Create", item); putError != nil {
+		helper.logger().Error("Error in creating an item", "error", putError)
+		tracing.RecordError(span, putError)
+		return nil, putError
 	}
 	return data, nil
 }
 
 func (helper *DatabaseHelper[T]) Fetch(partitionKey string, rangeKey string) (*T, *error) {
-	selectedKeys := map[string]types.AttributeValue{
-		"partition_key": &types.AttributeValueMemberS{Value: partitionKey},
-		"range_key":     &types.AttributeValueMemberS{Value: rangeKey},
-	}
-	itemOutput, err := helper.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
-		TableName: helper.TableName,
-		Key:       selectedKeys,
+	result, err := helper.FetchWithContext(context.Background(), partitionKey, rangeKey)
+	return result, errorPointer(err)
+}
+
+func (helper *DatabaseHelper[T]) FetchWithContext(ctx context.Context, partitionKey string, rangeKey string) (*T, error) {
+	ctx, span := helper.startSpan(ctx, "Fetch")
+	defer span.End()
+	itemOutput, err := helper.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:              helper.TableName,
+		Key:                    selectedKeys(partitionKey, rangeKey),
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
 	})
 	if err != nil {
-		log.Printf("Error in Fetching an item: %s", err.Error())
-		return nil, &err
+		helper.logger().Error("Error in fetching an item", "partition_key", partitionKey, "range_key", rangeKey, "error", err)
+		tracing.RecordError(span, err)
+		return nil, err
 	}
+	helper.reportCapacity(ctx, "Fetch", itemOutput.ConsumedCapacity)
 	if itemOutput.Item == nil {
-		log.Printf("No item found: %s", rangeKey)
+		helper.logger().Debug("No item found", "partition_key", partitionKey, "range_key", rangeKey)
 		return nil, nil
 	}
-	return helper.Converter.ConvertToModel(itemOutput.Item)
+	if helper.Offloader != nil {
+		if rehydrateError := helper.Offloader.Rehydrate(ctx, itemOutput.Item); rehydrateError != nil {
+			helper.logger().Error("Error in loading large attributes", "partition_key", partitionKey, "range_key", rangeKey, "error", rehydrateError)
+			tracing.RecordError(span, rehydrateError)
+			return nil, rehydrateError
+		}
+	}
+	model, converterError := helper.Converter.ConvertToModel(itemOutput.Item)
+	if converterError != nil {
+		tracing.RecordError(span, *converterError)
+		return nil, *converterError
+	}
+	return model, nil
 }
 
+// FetchAll leaves out the items of the page that can't be read and logs them; the rest of the page and
+// the last range key are still returned so paging can go on. Use FetchAllWithContext to get the error.
 func (helper *DatabaseHelper[T]) FetchAll(partitionKey string, limit int32, lastRangeKey *string) ([]*T, *string) {
+	items, lastKey, _ := helper.FetchAllWithContext(context.Background(), partitionKey, limit, lastRangeKey)
+	return items, lastKey
+}
+
+// FetchAllWithContext returns the readable items of one page and the last range key together with an
+// error that names every item it had to leave out, or the error of the query.
+func (helper *DatabaseHelper[T]) FetchAllWithContext(ctx context.Context, partitionKey string, limit int32, lastRangeKey *string) ([]*T, *string, error) {
+	ctx, span := helper.startSpan(ctx, "FetchAll")
+	defer span.End()
+	items, lastKey, err := helper.fetchPage(ctx, partitionKey, limit, lastRangeKey)
+	if err != nil {
+		tracing.RecordError(span, err)
+	}
+	return items, lastKey, err
+}
+
+// fetchPage returns the readable items of one page together with an error that names every item it
+// had to leave out.
+func (helper *DatabaseHelper[T]) fetchPage(ctx context.Context, partitionKey string, limit int32, lastRangeKey *string) ([]*T, *string, error) {
 	input := &dynamodb.QueryInput{
 		TableName:              helper.TableName,
 		KeyConditionExpression: aws.String("partition_key = :partitionKey"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
 			":partitionKey": &types.AttributeValueMemberS{Value: partitionKey},
 		},
-		Limit: aws.Int32(limit),
+		Limit:                  aws.Int32(limit),
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
 	}
 	if lastRangeKey != nil && len(*lastRangeKey) > 0 {
-		log.Printf("Trying to use a last range key of: %s", *lastRangeKey)
-		input.ExclusiveStartKey = map[string]types.AttributeValue{
-			"partition_key": &types.AttributeValueMemberS{Value: partitionKey},
-			"range_key":     &types.AttributeValueMemberS{Value: *lastRangeKey},
-		}
+		helper.logger().Debug("Using a last range key", "partition_key", partitionKey, "range_key", *lastRangeKey)
+		input.ExclusiveStartKey = selectedKeys(partitionKey, *lastRangeKey)
 	}
-	result, err := helper.Client.Query(context.TODO(), input)
+	result, err := helper.Client.Query(ctx, input)
 	if err != nil {
-		log.Printf("Error in Fetching an item: %s", err.Error())
-		return nil, nil
+		helper.logger().Error("Error in querying items", "partition_key", partitionKey, "error", err)
+		return nil, nil, err
 	}
+	helper.reportCapacity(ctx, "FetchAll", result.ConsumedCapacity)
 	var items []*T
+	var failures []error
 	for _, item := range result.Items {
-		pipeline, err := helper.Converter.ConvertToModel(item)
+		rangeKey := converter.ToString("range_key", item)
+		if helper.Offloader != nil {
+			if rehydrateError := helper.Offloader.Rehydrate(ctx, item); rehydrateError != nil {
+				helper.logger().Error("Error in loading large attributes", "partition_key", partitionKey, "range_key", rangeKey, "error", rehydrateError)
+				failures = append(failures, fmt.Errorf("item %s: %w", rangeKey, rehydrateError))
+				continue
+			}
+		}
+		model, err := helper.Converter.ConvertToModel(item)
 		if err != nil {
-			log.Printf("Error in parsing item: %s", errors.Unwrap(*err).Error())
+			helper.logger().Error("Error in parsing item", "partition_key", partitionKey, "range_key", rangeKey, "error", *err)
+			failures = append(failures, fmt.Errorf("item %s: %w", rangeKey, *err))
+			continue
 		}
-		items = append(items, pipeline)
+		items = append(items, model)
 	}
 	if _, ok := result.LastEvaluatedKey["range_key"]; ok {
 		lastKey := converter.ToString("range_key", result.LastEvaluatedKey)
-		return items, &lastKey
+		return items, &lastKey, errors.Join(failures...)
 	}
-	return items, nil
+	return items, nil, errors.Join(failures...)
 }
 
 func (helper *DatabaseHelper[T]) Update(data T) bool {
+	return helper.UpdateWithContext(context.Background(), data) == nil
+}
+
+func (helper *DatabaseHelper[T]) UpdateWithContext(ctx context.Context, data T) error {
+	ctx, span := helper.startSpan(ctx, "Update")
+	defer span.End()
 	item, converterError := helper.Converter.ConvertToItem(&data)
 	if converterError != nil {
-		log.Printf("Error in converting the model")
-		return false
+		helper.logger().Error("Error in converting the model", "error", *converterError)
+		tracing.RecordError(span, *converterError)
+		return *converterError
 	}
-	_, err := helper.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
-		TableName: helper.TableName,
-		Item:      item,
-	})
-	if err != nil {
-		log.Printf("Error in updating an item: %s", err.Error())
-		return false
+	if err := helper.putItem(ctx, "Update", item); err != nil {
+		helper.logger().Error("Error in updating an item", "error", err)
+		tracing.RecordError(span, err)
+		return err
 	}
-	return true
+	return nil
 }
 
 func (helper *DatabaseHelper[T]) Delete(partitionKey string, rangeKey string) bool {
-	selectedKeys := map[string]types.AttributeValue{
-		"partition_key": &types.AttributeValueMemberS{Value: partitionKey},
-		"range_key":     &types.AttributeValueMemberS{Value: rangeKey},
+	return helper.DeleteWithContext(context.Background(), partitionKey, rangeKey) == nil
+}
+
+func (helper *DatabaseHelper[T]) DeleteWithContext(ctx context.Context, partitionKey string, rangeKey string) error {
+	ctx, span := helper.startSpan(ctx, "Delete")
+	defer span.End()
+	deleteInput := &dynamodb.DeleteItemInput{
+		TableName:              helper.TableName,
+		Key:                    selectedKeys(partitionKey, rangeKey),
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
 	}
-	_, err := helper.Client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
-		TableName: helper.TableName,
-		Key:       selectedKeys,
-	})
+	if helper.Offloader != nil {
+		deleteInput.ReturnValues = types.ReturnValueAllOld
+	}
+	deleteOutput, err := helper.Client.DeleteItem(ctx, deleteInput)
 	if err != nil {
-		log.Printf("Error in deleting an item: %s", err.Error())
-		return false
+		helper.logger().Error("Error in deleting an item", "partition_key", partitionKey, "range_key", rangeKey, "error", err)
+		tracing.RecordError(span, err)
+		return err
+	}
+	helper.reportCapacity(ctx, "Delete", deleteOutput.ConsumedCapacity)
+	if helper.Offloader != nil {
+		helper.Offloader.Remove(ctx, deleteOutput.Attributes)
+	}
+	return nil
+}
+
+// putItem writes item and keeps its large attributes in the Offloader. The blobs of a failed write are
+// deleted again, and so are the blobs of the item it replaced.
+func (helper *DatabaseHelper[T]) putItem(ctx context.Context, operation string, item map[string]types.AttributeValue) error {
+	var blobKeys []string
+	if helper.Offloader != nil {
+		written, err := helper.Offloader.Offload(ctx, *helper.TableName, item)
+		if err != nil {
+			return fmt.Errorf("could not offload large attributes: %w", err)
+		}
+		blobKeys = written
+	}
+	if sizeError := helper.checkItemSize(item); sizeError != nil {
+		helper.discardBlobs(ctx, blobKeys)
+		return sizeError
+	}
+	input := &dynamodb.PutItemInput{
+		TableName:              helper.TableName,
+		Item:                   item,
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+	}
+	if helper.Offloader != nil {
+		input.ReturnValues = types.ReturnValueAllOld
+	}
+	output, err := helper.Client.PutItem(ctx, input)
+	if err != nil {
+		helper.discardBlobs(ctx, blobKeys)
+		return err
+	}
+	helper.reportCapacity(ctx, operation, output.ConsumedCapacity)
+	if helper.Offloader != nil {
+		helper.Offloader.Remove(ctx, output.Attributes)
+	}
+	return nil
+}
+
+func (helper *DatabaseHelper[T]) discardBlobs(ctx context.Context, blobKeys []string) {
+	if helper.Offloader != nil {
+		helper.Offloader.Discard(ctx, blobKeys)
+	}
+}
+
+func (helper *DatabaseHelper[T]) logger() *slog.Logger {
+	return utils.LoggerOrNop(helper.Logger).With("table", aws.ToString(helper.TableName))
+}
+
+func errorPointer(err error) *error {
+	if err == nil {
+		return nil
+	}
+	return &err
+}
+
+// startSpan starts the span of one helper operation; the consumed capacity is added by reportCapacity.
+func (helper *DatabaseHelper[T]) startSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return tracing.Tracer(helper.TracerProvider).Start(tracing.Context(ctx), "DynamoDB "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			tracing.AttributeDbSystem.String("dynamodb"),
+			tracing.AttributeOperation.String(operation),
+			tracing.AttributeTableNames.StringSlice([]string{aws.ToString(helper.TableName)}),
+		),
+	)
+}
+
+// checkItemSize fails before the write when DynamoDB would reject the item for its size.
+func (helper *DatabaseHelper[T]) checkItemSize(item map[string]types.AttributeValue) error {
+	size := converter.ItemSize(item)
+	if size <= converter.MaxItemSize {
+		return nil
+	}
+	largestName, largestSize := "", 0
+	for name, value := range item {
+		if attributeSize := converter.AttributeSize(name, value); attributeSize > largestSize {
+			largestName, largestSize = name, attributeSize
+		}
+	}
+	return fmt.Errorf("%w: item for table %s is %d bytes, limit is %d bytes, largest attribute %s is %d bytes",
+		ErrItemTooLarge, aws.ToString(helper.TableName), size, converter.MaxItemSize, largestName, largestSize)
+}
+
+func (helper *DatabaseHelper[T]) reportCapacity(ctx context.Context, operation string, capacity *types.ConsumedCapacity) {
+	if capacity == nil || capacity.CapacityUnits == nil {
+		return
+	}
+	trace.SpanFromContext(ctx).SetAttributes(tracing.AttributeConsumedCapacity.Float64(*capacity.CapacityUnits))
+	if helper.MetricsManager == nil {
+		return
 	}
-	return true
+	metrics.Counter(helper.MetricsManager, "ConsumedCapacity", *capacity.CapacityUnits,
+		metrics.Dim("Table", aws.ToString(helper.TableName)),
+		metrics.Dim(metrics.DimensionOperation, operation),
+	)
 }
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nicholaspark09/awsgorocket/converter"
+	"strings"
+	"testing"
+)
+
+func sizedItem(size int) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"name":  &types.AttributeValueMemberS{Value: "Ana"},
+		"notes": &types.AttributeValueMemberS{Value: strings.Repeat("a", size-len("name")-len("Ana")-len("notes"))},
+	}
+}
+
+func TestCheckItemSize(t *testing.T) {
+	helper := &DatabaseHelper[profile]{TableName: aws.String("profiles")}
+	if err := helper.checkItemSize(sizedItem(converter.MaxItemSize)); err != nil {
+		t.Fatalf("checkItemSize at the limit = %v, want nil", err)
+	}
+	err := helper.checkItemSize(sizedItem(converter.MaxItemSize + 1))
+	if !errors.Is(err, ErrItemTooLarge) {
+		t.Fatalf("checkItemSize above the limit = %v, want ErrItemTooLarge", err)
+	}
+	want := fmt.Sprintf("item for table profiles is %d bytes, limit is %d bytes, largest attribute notes is %d bytes",
+		converter.MaxItemSize+1, converter.MaxItemSize, converter.MaxItemSize+1-len("name")-len("Ana"))
+	if !strings.Contains(err.Error(), want) {
+		t.Fatalf("error = %q, want it to contain %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nicholaspark09/awsgorocket/converter"
+	"github.com/nicholaspark09/awsgorocket/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"slices"
+	"strings"
+)
+
+// ErrPathNotSupported is returned for document paths into attributes that only the model converter or
+// the Offloader can read and write, such as encrypted, signed, compressed or offloaded attributes.
+var ErrPathNotSupported = errors.New("document path is not supported for this attribute")
+
+// FetchPaths reads only the given document paths of an item, e.g. "address.city" or "tags[0]".
+// The partial item is returned as is, so read it with converter.GetPath rather than the model converter.
+// Paths into an attribute the item keeps in the Offloader fail with ErrPathNotSupported; use Fetch.
+func (helper *DatabaseHelper[T]) FetchPaths(partitionKey string, rangeKey string, paths ...string) (map[string]types.AttributeValue, *error) {
+	item, err := helper.FetchPathsWithContext(context.Background(), partitionKey, rangeKey, paths...)
+	return item, errorPointer(err)
+}
+
+func (helper *DatabaseHelper[T]) FetchPathsWithContext(ctx context.Context, partitionKey string, rangeKey string, paths ...string) (map[string]types.AttributeValue, error) {
+	ctx, span := helper.startSpan(ctx, "FetchPaths")
+	defer span.End()
+	names := map[string]string{}
+	projections := make([]string, 0, len(paths))
+	for _, path := range paths {
+		projection, attribute, err := helper.pathExpression(path, names)
+		if err != nil {
+			helper.logger().Error("Error in parsing path", "path", path, "error", err)
+			tracing.RecordError(span, err)
+			return nil, err
+		}
+		projections = append(projections, projection)
+		if helper.Offloader != nil {
+			projections = append(projections, offloadedPointerPath(attribute, names))
+		}
+	}
+	itemOutput, err := helper.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:                helper.TableName,
+		Key:                      selectedKeys(partitionKey, rangeKey),
+		ProjectionExpression:     aws.String(strings.Join(projections, ", ")),
+		ExpressionAttributeNames: names,
+		ReturnConsumedCapacity:   types.ReturnConsumedCapacityTotal,
+	})
+	if err != nil {
+		helper.logger().Error("Error in fetching an item", "partition_key", partitionKey, "range_key", rangeKey, "error", err)
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	helper.reportCapacity(ctx, "FetchPaths", itemOutput.ConsumedCapacity)
+	if itemOutput.Item == nil {
+		helper.logger().Debug("No item found", "partition_key", partitionKey, "range_key", rangeKey)
+		return nil, nil
+	}
+	if _, offloaded := itemOutput.Item[OffloadedAttribute]; offloaded {
+		offloadedError := fmt.Errorf("%w: the item keeps a requested attribute in the blob store", ErrPathNotSupported)
+		helper.logger().Error("Error in fetching paths", "partition_key", partitionKey, "range_key", rangeKey, "error", offloadedError)
+		tracing.RecordError(span, offloadedError)
+		return nil, offloadedError
+	}
+	return itemOutput.Item, nil
+}
+
+// UpdatePath sets a single document path without rewriting the rest of the item. The value is written
+// as is, so it fails with ErrPathNotSupported for attributes the model converter transforms, for
+// attributes the item keeps in the Offloader and for values the Offloader would move out of the item.
+func (helper *DatabaseHelper[T]) UpdatePath(partitionKey string, rangeKey string, path string, value types.AttributeValue) bool {
+	return helper.UpdatePathWithContext(context.Background(), partitionKey, rangeKey, path, value) == nil
+}
+
+func (helper *DatabaseHelper[T]) UpdatePathWithContext(ctx context.Context, partitionKey string, rangeKey string, path string, value types.AttributeValue) error {
+	ctx, span := helper.startSpan(ctx, "UpdatePath")
+	defer span.End()
+	names := map[string]string{}
+	expressionPath, attribute, err := helper.pathExpression(path, names)
+	if err == nil {
+		err = helper.checkPathValue(path, value)
+	}
+	if err != nil {
+		helper.logger().Error("Error in updating a path", "path", path, "error", err)
+		tracing.RecordError(span, err)
+		return err
+	}
+	return helper.updateItem(ctx, "UpdatePath", &dynamodb.UpdateItemInput{
+		TableName:                 helper.TableName,
+		Key:                       selectedKeys(partitionKey, rangeKey),
+		UpdateExpression:          aws.String("SET " + expressionPath + " = :value"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: map[string]types.AttributeValue{":value": value},
+		ConditionExpression:       aws.String(helper.pathCondition(attribute, names)),
+		ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+	})
+}
+
+// RemovePath deletes a single document path from an item. Like UpdatePath, it refuses attributes the
+// model converter transforms or the Offloader keeps.
+func (helper *DatabaseHelper[T]) RemovePath(partitionKey string, rangeKey string, path string) bool {
+	return helper.RemovePathWithContext(context.Background(), partitionKey, rangeKey, path) == nil
+}
+
+func (helper *DatabaseHelper[T]) RemovePathWithContext(ctx context.Context, partitionKey string, rangeKey string, path string) error {
+	ctx, span := helper.startSpan(ctx, "RemovePath")
+	defer span.End()
+	names := map[string]string{}
+	expressionPath, attribute, err := helper.pathExpression(path, names)
+	if err != nil {
+		helper.logger().Error("Error in removing a path", "path", path, "error", err)
+		tracing.RecordError(span, err)
+		return err
+	}
+	return helper.updateItem(ctx, "RemovePath", &dynamodb.UpdateItemInput{
+		TableName:                helper.TableName,
+		Key:                      selectedKeys(partitionKey, rangeKey),
+		UpdateExpression:         aws.String("REMOVE " + expressionPath),
+		ExpressionAttributeNames: names,
+		ConditionExpression:      aws.String(helper.pathCondition(attribute, names)),
+		ReturnConsumedCapacity:   types.ReturnConsumedCapacityTotal,
+	})
+}
+
+func (helper *DatabaseHelper[T]) updateItem(ctx context.Context, operation string, input *dynamodb.UpdateItemInput) error {
+	updateOutput, err := helper.Client.UpdateItem(ctx, input)
+	if err != nil {
+		helper.logger().Error("Error in updating an item", "operation", operation, "error", err)
+		tracing.RecordError(trace.SpanFromContext(ctx), err)
+		return err
+	}
+	helper.reportCapacity(ctx, operation, updateOutput.ConsumedCapacity)
+	return nil
+}
+
+// pathExpression returns the expression of path and its top-level attribute, refusing attributes that
+// the model converter transforms and the reserved attributes of the helper.
+func (helper *DatabaseHelper[T]) pathExpression(path string, names map[string]string) (string, string, error) {
+	elements, err := converter.ParsePath(path)
+	if err != nil {
+		return "", "", err
+	}
+	attribute := elements[0].Name
+	if attribute == OffloadedAttribute || slices.Contains(converter.TransformedAttributes(helper.Converter), attribute) {
+		return "", "", fmt.Errorf("%w: %s is written by the model converter", ErrPathNotSupported, attribute)
+	}
+	expressionPath, err := converter.ExpressionPath(path, names)
+	return expressionPath, attribute, err
+}
+
+// checkPathValue refuses values that could not be stored in the item as they are.
+func (helper *DatabaseHelper[T]) checkPathValue(path string, value types.AttributeValue) error {
+	size := converter.AttributeSize(path, value)
+	if size > converter.MaxItemSize {
+		return fmt.Errorf("%w: value for path %s is %d bytes, limit is %d bytes", ErrItemTooLarge, path, size, converter.MaxItemSize)
+	}
+	if helper.Offloader != nil && size > helper.Offloader.Threshold {
+		return fmt.Errorf("%w: value for path %s is %d bytes, above the offload threshold; use Update", ErrPathNotSupported, path, size)
+	}
+	return nil
+}
+
+// pathCondition requires the item to exist and, with an Offloader, attribute to still be in the item.
+func (helper *DatabaseHelper[T]) pathCondition(attribute string, names map[string]string) string {
+	condition := "attribute_exists(partition_key)"
+	if helper.Offloader != nil {
+		condition += " AND attribute_not_exists(" + offloadedPointerPath(attribute, names) + ")"
+	}
+	return condition
+}
+
+func offloadedPointerPath(attribute string, names map[string]string) string {
+	pointerPath, _ := converter.ExpressionPath(OffloadedAttribute+"."+attribute, names)
+	return pointerPath
+}
+
+func selectedKeys(partitionKey string, rangeKey string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"partition_key": &types.AttributeValueMemberS{Value: partitionKey},
+		"range_key":     &types.AttributeValueMemberS{Value: rangeKey},
+	}
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nicholaspark09/awsgorocket/blobstore"
+	"github.com/nicholaspark09/awsgorocket/converter"
+	"strings"
+	"testing"
+)
+
+type profile struct {
+	Name string
+}
+
+type profileConverter struct{}
+
+func (profileConverter) ConvertToItem(data *profile) (map[string]types.AttributeValue, *error) {
+	return map[string]types.AttributeValue{"name": &types.AttributeValueMemberS{Value: data.Name}}, nil
+}
+
+func (profileConverter) ConvertToModel(item map[string]types.AttributeValue) (*profile, *error) {
+	return &profile{Name: converter.ToString("name", item)}, nil
+}
+
+func pathHelper(t *testing.T) *DatabaseHelper[profile] {
+	compressed := converter.ProvideCompressedModelConverter[profile](profileConverter{}, map[string]converter.Codec{
+		"biography": converter.Gzip,
+	}, nil)
+	return &DatabaseHelper[profile]{
+		TableName: aws.String("profiles"),
+		Converter: compressed,
+		Offloader: ProvideLargeAttributeOffloader(blobstore.ProvideFileBlobStore(t.TempDir()), 1024),
+	}
+}
+
+func TestPathExpressionRefusesTransformedAttributes(t *testing.T) {
+	helper := pathHelper(t)
+	for _, path := range []string{"biography", "biography.summary", OffloadedAttribute + ".biography"} {
+		if _, _, err := helper.pathExpression(path, map[string]string{}); !errors.Is(err, ErrPathNotSupported) {
+			t.Fatalf("pathExpression(%q) = %v, want ErrPathNotSupported", path, err)
+		}
+	}
+	expression, attribute, err := helper.pathExpression("address.city", map[string]string{})
+	if err != nil {
+		t.Fatalf("pathExpression: %v", err)
+	}
+	if expression != "#n0.#n1" || attribute != "address" {
+		t.Fatalf("pathExpression = %q, %q", expression, attribute)
+	}
+}
+
+func TestPathsRefusedBeforeAnyRequest(t *testing.T) {
+	helper := pathHelper(t)
+	if helper.UpdatePath("customer#1", "profile", "biography", &types.AttributeValueMemberS{Value: "text"}) {
+		t.Fatal("UpdatePath wrote a compressed attribute")
+	}
+	if helper.RemovePath("customer#1", "profile", "biography") {
+		t.Fatal("RemovePath removed a compressed attribute")
+	}
+	if _, err := helper.FetchPaths("customer#1", "profile", "name", "biography"); err == nil || !errors.Is(*err, ErrPathNotSupported) {
+		t.Fatalf("FetchPaths of a compressed attribute = %v, want ErrPathNotSupported", err)
+	}
+}
+
+func TestPathWithContextReturnsTheError(t *testing.T) {
+	helper := pathHelper(t)
+	ctx := context.Background()
+	if err := helper.UpdatePathWithContext(ctx, "customer#1", "profile", "biography", &types.AttributeValueMemberS{Value: "text"}); !errors.Is(err, ErrPathNotSupported) {
+		t.Fatalf("UpdatePathWithContext = %v, want ErrPathNotSupported", err)
+	}
+	if err := helper.RemovePathWithContext(ctx, "customer#1", "profile", "biography"); !errors.Is(err, ErrPathNotSupported) {
+		t.Fatalf("RemovePathWithContext = %v, want ErrPathNotSupported", err)
+	}
+}
+
+func TestCheckPathValue(t *testing.T) {
+	helper := pathHelper(t)
+	if err := helper.checkPathValue("address.city", &types.AttributeValueMemberS{Value: "Seattle"}); err != nil {
+		t.Fatalf("checkPathValue of a small value: %v", err)
+	}
+	offloadable := &types.AttributeValueMemberS{Value: strings.Repeat("a", 2048)}
+	if err := helper.checkPathValue("notes", offloadable); !errors.Is(err, ErrPathNotSupported) {
+		t.Fatalf("checkPathValue above the offload threshold = %v, want ErrPathNotSupported", err)
+	}
+	tooLarge := &types.AttributeValueMemberS{Value: strings.Repeat("a", converter.MaxItemSize+1)}
+	if err := helper.checkPathValue("notes", tooLarge); !errors.Is(err, ErrItemTooLarge) {
+		t.Fatalf("checkPathValue above the item limit = %v, want ErrItemTooLarge", err)
+	}
+}
+
+func TestPathConditionGuardsOffloadedAttributes(t *testing.T) {
+	names := map[string]string{}
+	condition := pathHelper(t).pathCondition("notes", names)
+	if condition != "attribute_exists(partition_key) AND attribute_not_exists(#n0.#n1)" {
+		t.Fatalf("pathCondition = %q", condition)
+	}
+	if names["#n0"] != OffloadedAttribute || names["#n1"] != "notes" {
+		t.Fatalf("pathCondition names = %v", names)
+	}
+}
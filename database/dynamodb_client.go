@@ -0,0 +1,14 @@
+package database
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/nicholaspark09/awsgorocket/config"
+)
+
+// ProvideDynamoDbClient builds the client for DatabaseHelper.Client, pointed at the DynamoDB endpoint
+// of the environment profile when it overrides one, e.g. DynamoDB Local for "local".
+func ProvideDynamoDbClient(provider config.ConfigProvider) *dynamodb.Client {
+	return dynamodb.NewFromConfig(provider.SdkConfig, func(options *dynamodb.Options) {
+		options.BaseEndpoint = provider.Environment.BaseEndpoint(config.ServiceDynamoDb)
+	})
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/nicholaspark09/awsgorocket/metrics"
+	"time"
+)
+
+var errOperationFailed = errors.New("operation failed")
+
+// InstrumentedDatabaseHelper records the outcome of every call of the helper it wraps with
+// metrics.RecordOutcome, so failed calls are also counted by error class. Operations are named
+// "<name>.<method>", e.g. "Orders.Fetch". It implements both DatabaseHelperContract and
+// DatabaseHelperContextContract.
+type InstrumentedDatabaseHelper[T any] struct {
+	helper         DatabaseHelperContextContract[T]
+	metricsManager metrics.MetricsManagerContract
+	name           string
+}
+
+// ProvideInstrumentedDatabaseHelper wraps helper; name is usually the table. When helper also
+// implements DatabaseHelperContextContract, as *DatabaseHelper does, the errors it returns are
+// classified. Other helpers only report a false or nil result, which counts as 5XX, and their
+// FetchAll failures are not seen at all.
+func ProvideInstrumentedDatabaseHelper[T any](helper DatabaseHelperContract[T], metricsManager metrics.MetricsManagerContract, name string) *InstrumentedDatabaseHelper[T] {
+	contextHelper, ok := helper.(DatabaseHelperContextContract[T])
+	if !ok {
+		contextHelper = contractHelper[T]{helper: helper}
+	}
+	return &InstrumentedDatabaseHelper[T]{
+		helper:         contextHelper,
+		metricsManager: metricsManager,
+		name:           name,
+	}
+}
+
+func (instrumented *InstrumentedDatabaseHelper[T]) Create(data *T) (*T, *error) {
+	result, err := instrumented.CreateWithContext(context.Background(), data)
+	return result, errorPointer(err)
+}
+
+func (instrumented *InstrumentedDatabaseHelper[T]) Fetch(partitionKey string, rangeKey string) (*T, *error) {
+	result, err := instrumented.FetchWithContext(context.Background(), partitionKey, rangeKey)
+	return result, errorPointer(err)
+}
+
+func (instrumented *InstrumentedDatabaseHelper[T]) FetchAll(partitionKey string, limit int32, lastRangeKey *string) ([]*T, *string) {
+	items, lastKey, _ := instrumented.FetchAllWithContext(context.Background(), partitionKey, limit, lastRangeKey)
+	return items, lastKey
+}
+
+func (instrumented *InstrumentedDatabaseHelper[T]) Update(data T) bool {
+	return instrumented.UpdateWithContext(context.Background(), data) == nil
+}
+
+func (instrumented *InstrumentedDatabaseHelper[T]) Delete(partitionKey string, rangeKey string) bool {
+	return instrumented.DeleteWithContext(context.Background(), partitionKey, rangeKey) == nil
+}
+
+func (instrumented *InstrumentedDatabaseHelper[T]) CreateWithContext(ctx context.Context, data *T) (*T, error) {
+	start := time.Now()
+	result, err := instrumented.helper.CreateWithContext(ctx, data)
+	instrumented.record("Create", start, err)
+	return result, err
+}
+
+func (instrumented *InstrumentedDatabaseHelper[T]) FetchWithContext(ctx context.Context, partitionKey string, rangeKey string) (*T, error) {
+	start := time.Now()
+	result, err := instrumented.helper.FetchWithContext(ctx, partitionKey, rangeKey)
+	instrumented.record("Fetch", start, err)
+	return result, err
+}
+
+func (instrumented *InstrumentedDatabaseHelper[T]) FetchAllWithContext(ctx context.Context, partitionKey string, limit int32, lastRangeKey *string) ([]*T, *string, error) {
+	start := time.Now()
+	items, lastKey, err := instrumented.helper.FetchAllWithContext(ctx, partitionKey, limit, lastRangeKey)
+	instrumented.record("FetchAll", start, err)
+	return items, lastKey, err
+}
+
+func (instrumented *InstrumentedDatabaseHelper[T]) UpdateWithContext(ctx context.Context, data T) error {
+	start := time.Now()
+	err := instrumented.helper.UpdateWithContext(ctx, data)
+	instrumented.record("Update", start, err)
+	return err
+}
+
+func (instrumented *InstrumentedDatabaseHelper[T]) DeleteWithContext(ctx context.Context, partitionKey string, rangeKey string) error {
+	start := time.Now()
+	err := instrumented.helper.DeleteWithContext(ctx, partitionKey, rangeKey)
+	instrumented.record("Delete", start, err)
+	return err
+}
+
+func (instrumented *InstrumentedDatabaseHelper[T]) record(method string, start time.Time, err error) {
+	metrics.RecordOutcome(instrumented.metricsManager, instrumented.name+"."+method, time.Since(start), err)
+}
+
+// contractHelper lets a helper that only implements DatabaseHelperContract be instrumented. It
+// ignores the context and reports a false result as errOperationFailed.
+type contractHelper[T any] struct {
+	helper DatabaseHelperContract[T]
+}
+
+func (adapted contractHelper[T]) CreateWithContext(ctx context.Context, data *T) (*T, error) {
+	result, err := adapted.helper.Create(data)
+	return result, errorValue(err)
+}
+
+func (adapted contractHelper[T]) FetchWithContext(ctx context.Context, partitionKey string, rangeKey string) (*T, error) {
+	result, err := adapted.helper.Fetch(partitionKey, rangeKey)
+	return result, errorValue(err)
+}
+
+func (adapted contractHelper[T]) FetchAllWithContext(ctx context.Context, partitionKey string, limit int32, lastRangeKey *string) ([]*T, *string, error) {
+	items, lastKey := adapted.helper.FetchAll(partitionKey, limit, lastRangeKey)
+	return items, lastKey, nil
+}
+
+func (adapted contractHelper[T]) UpdateWithContext(ctx context.Context, data T) error {
+	if !adapted.helper.Update(data) {
+		return errOperationFailed
+	}
+	return nil
+}
+
+func (adapted contractHelper[T]) DeleteWithContext(ctx context.Context, partitionKey string, rangeKey string) error {
+	if !adapted.helper.Delete(partitionKey, rangeKey) {
+		return errOperationFailed
+	}
+	return nil
+}
+
+// errorValue turns the *error of DatabaseHelperContract into an error; a pointer to a nil error
+// counts as errOperationFailed.
+func errorValue(err *error) error {
+	if err == nil {
+		return nil
+	}
+	if *err == nil {
+		return errOperationFailed
+	}
+	return *err
+}
+
+var _ DatabaseHelperContract[any] = (*DatabaseHelper[any])(nil)
+var _ DatabaseHelperContextContract[any] = (*DatabaseHelper[any])(nil)
+var _ DatabaseHelperContract[any] = (*InstrumentedDatabaseHelper[any])(nil)
+var _ DatabaseHelperContextContract[any] = (*InstrumentedDatabaseHelper[any])(nil)
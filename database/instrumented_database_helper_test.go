@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nicholaspark09/awsgorocket/metrics"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingManager keeps the counters it is sent by name and dimensions.
+type countingManager struct {
+	counters map[string]float64
+}
+
+func (manager *countingManager) SendMeasuredTime(callName string, time time.Duration) {}
+
+func (manager *countingManager) SendLog(tag string, message string) {}
+
+func (manager *countingManager) Send500Error(callName string, statusCode int, message string) {}
+
+func (manager *countingManager) Send400Error(callName string, statusCode int, message string) {}
+
+func (manager *countingManager) Counter(name string, value float64, dimensions ...metrics.Dimension) {
+	key := name
+	for _, dimension := range dimensions {
+		key += " " + dimension.Name + "=" + dimension.Value
+	}
+	manager.counters[key] += value
+}
+
+func (manager *countingManager) Gauge(name string, value float64, unit metrics.Unit, dimensions ...metrics.Dimension) {
+}
+
+func (manager *countingManager) Histogram(name string, value float64, unit metrics.Unit, dimensions ...metrics.Dimension) {
+}
+
+func (manager *countingManager) Timer(name string, duration time.Duration, dimensions ...metrics.Dimension) {
+}
+
+type contextKey struct{}
+
+// fakeContextHelper fails Update with updateError and remembers the context it was called with.
+type fakeContextHelper struct {
+	updateError error
+	seen        context.Context
+}
+
+func (helper *fakeContextHelper) Create(data *profile) (*profile, *error) { return data, nil }
+
+func (helper *fakeContextHelper) Fetch(partitionKey string, rangeKey string) (*profile, *error) {
+	return nil, nil
+}
+
+func (helper *fakeContextHelper) FetchAll(partitionKey string, limit int32, lastRangeKey *string) ([]*profile, *string) {
+	return nil, nil
+}
+
+func (helper *fakeContextHelper) Update(data profile) bool { return helper.updateError == nil }
+
+func (helper *fakeContextHelper) Delete(partitionKey string, rangeKey string) bool { return true }
+
+func (helper *fakeContextHelper) CreateWithContext(ctx context.Context, data *profile) (*profile, error) {
+	return data, nil
+}
+
+func (helper *fakeContextHelper) FetchWithContext(ctx context.Context, partitionKey string, rangeKey string) (*profile, error) {
+	return nil, nil
+}
+
+func (helper *fakeContextHelper) FetchAllWithContext(ctx context.Context, partitionKey string, limit int32, lastRangeKey *string) ([]*profile, *string, error) {
+	return nil, nil, errors.New("page had unreadable items")
+}
+
+func (helper *fakeContextHelper) UpdateWithContext(ctx context.Context, data profile) error {
+	helper.seen = ctx
+	return helper.updateError
+}
+
+func (helper *fakeContextHelper) DeleteWithContext(ctx context.Context, partitionKey string, rangeKey string) error {
+	return nil
+}
+
+// contractOnlyHelper implements only DatabaseHelperContract, like helpers written before the
+// context variants.
+type contractOnlyHelper struct{}
+
+func (contractOnlyHelper) Create(data *profile) (*profile, *error) { return data, nil }
+
+func (contractOnlyHelper) Fetch(partitionKey string, rangeKey string) (*profile, *error) {
+	return nil, nil
+}
+
+func (contractOnlyHelper) FetchAll(partitionKey string, limit int32, lastRangeKey *string) ([]*profile, *string) {
+	return nil, nil
+}
+
+func (contractOnlyHelper) Update(data profile) bool { return false }
+
+func (contractOnlyHelper) Delete(partitionKey string, rangeKey string) bool { return true }
+
+func newCountingManager() *countingManager {
+	return &countingManager{counters: map[string]float64{}}
+}
+
+func TestInstrumentedHelperClassifiesReturnedErrors(t *testing.T) {
+	manager := newCountingManager()
+	helper := &fakeContextHelper{updateError: &types.ConditionalCheckFailedException{Message: new(string)}}
+	instrumented := ProvideInstrumentedDatabaseHelper[profile](helper, manager, "Profiles")
+	ctx := context.WithValue(context.Background(), contextKey{}, "request")
+	err := instrumented.UpdateWithContext(ctx, profile{Name: "Ada"})
+	var conditionalFailure *types.ConditionalCheckFailedException
+	if !errors.As(err, &conditionalFailure) {
+		t.Fatalf("UpdateWithContext = %v, want the helper's error", err)
+	}
+	if helper.seen == nil || helper.seen.Value(contextKey{}) != "request" {
+		t.Fatal("the helper was not called with the caller's context")
+	}
+	want := "Errors Operation=Profiles.Update ErrorClass=ConditionalFailure"
+	if manager.counters[want] != 1 {
+		t.Fatalf("counters = %v, want %q", manager.counters, want)
+	}
+}
+
+func TestInstrumentedHelperSeesFetchAllErrors(t *testing.T) {
+	manager := newCountingManager()
+	instrumented := ProvideInstrumentedDatabaseHelper[profile](&fakeContextHelper{}, manager, "Profiles")
+	instrumented.FetchAll("customer#1", 10, nil)
+	if manager.counters["Failure Operation=Profiles.FetchAll"] != 1 {
+		t.Fatalf("counters = %v, want a FetchAll failure", manager.counters)
+	}
+}
+
+func TestInstrumentedHelperCountsFalseResultsOfContractHelpers(t *testing.T) {
+	manager := newCountingManager()
+	instrumented := ProvideInstrumentedDatabaseHelper[profile](contractOnlyHelper{}, manager, "Profiles")
+	if instrumented.Update(profile{Name: "Ada"}) {
+		t.Fatal("Update succeeded")
+	}
+	for key, value := range manager.counters {
+		if strings.HasPrefix(key, "Errors ") && key != "Errors Operation=Profiles.Update ErrorClass=5XX" {
+			t.Fatalf("unexpected error count %q = %v", key, value)
+		}
+	}
+	if manager.counters["Errors Operation=Profiles.Update ErrorClass=5XX"] != 1 {
+		t.Fatalf("counters = %v, want a 5XX error", manager.counters)
+	}
+}
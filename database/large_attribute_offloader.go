@@ -0,0 +1,189 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nicholaspark09/awsgorocket/blobstore"
+	"github.com/nicholaspark09/awsgorocket/converter"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+const (
+	// OffloadedAttribute is reserved. It maps the name of every offloaded attribute to its
+	// {__blob_key, __blob_size} pointer, so user data is never mistaken for a pointer.
+	OffloadedAttribute = "__offloaded"
+	BlobKeyAttribute   = "__blob_key"
+	BlobSizeAttribute  = "__blob_size"
+)
+
+// LargeAttributeOffloader moves attributes whose billed size is above Threshold bytes into a BlobStore and
+// records a pointer to each of them in OffloadedAttribute. The attributes are swapped back on read.
+//
+// Every write stores its blobs under new keys, so a failed write never touches the blobs of the item
+// already in the table. The caller removes the new blobs with Discard when the write fails, and the
+// blobs of the replaced item with Remove when it succeeds.
+type LargeAttributeOffloader struct {
+	Store     blobstore.BlobStore
+	Threshold int
+	// Logger is optional; nothing is logged without one.
+	Logger *slog.Logger
+}
+
+func ProvideLargeAttributeOffloader(store blobstore.BlobStore, threshold int) *LargeAttributeOffloader {
+	return &LargeAttributeOffloader{
+		Store:     store,
+		Threshold: threshold,
+	}
+}
+
+// Offload stores the oversized attributes of item and returns the keys of the blobs it wrote.
+// When it fails, the blobs it already wrote are deleted again.
+func (offloader *LargeAttributeOffloader) Offload(ctx context.Context, tableName string, item map[string]types.AttributeValue) ([]string, error) {
+	if _, reserved := item[OffloadedAttribute]; reserved {
+		return nil, fmt.Errorf("attribute %s is reserved for offloaded attributes", OffloadedAttribute)
+	}
+	names := make([]string, 0, len(item))
+	for name := range item {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pointers := map[string]types.AttributeValue{}
+	var written []string
+	for _, name := range names {
+		value := item[name]
+		if name == "partition_key" || name == "range_key" {
+			continue
+		}
+		if converter.AttributeSize(name, value) <= offloader.Threshold {
+			continue
+		}
+		encoded, err := converter.MarshalAttributeValue(value)
+		if err != nil {
+			offloader.Discard(ctx, written)
+			return nil, err
+		}
+		blobKey, err := offloader.blobKey(tableName, item, name)
+		if err != nil {
+			offloader.Discard(ctx, written)
+			return nil, err
+		}
+		if err := offloader.Store.Put(ctx, blobKey, encoded); err != nil {
+			offloader.Discard(ctx, written)
+			return nil, fmt.Errorf("could not offload attribute %s: %w", name, err)
+		}
+		written = append(written, blobKey)
+		pointers[name] = &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			BlobKeyAttribute:  &types.AttributeValueMemberS{Value: blobKey},
+			BlobSizeAttribute: &types.AttributeValueMemberN{Value: strconv.Itoa(len(encoded))},
+		}}
+	}
+	if len(pointers) == 0 {
+		return nil, nil
+	}
+	for name := range pointers {
+		delete(item, name)
+	}
+	item[OffloadedAttribute] = &types.AttributeValueMemberM{Value: pointers}
+	return written, nil
+}
+
+// Rehydrate loads every offloaded attribute of item back in place and drops OffloadedAttribute.
+func (offloader *LargeAttributeOffloader) Rehydrate(ctx context.Context, item map[string]types.AttributeValue) error {
+	pointers, err := offloadedPointers(item)
+	if err != nil {
+		return err
+	}
+	for name, blobKey := range pointers {
+		encoded, err := offloader.Store.Get(ctx, blobKey)
+		if err != nil {
+			return fmt.Errorf("could not load offloaded attribute %s: %w", name, err)
+		}
+		stored, err := converter.UnmarshalAttributeValue(encoded)
+		if err != nil {
+			return fmt.Errorf("could not decode offloaded attribute %s: %w", name, err)
+		}
+		item[name] = stored
+	}
+	delete(item, OffloadedAttribute)
+	return nil
+}
+
+// Remove deletes every blob the item points at.
+func (offloader *LargeAttributeOffloader) Remove(ctx context.Context, item map[string]types.AttributeValue) {
+	pointers, err := offloadedPointers(item)
+	if err != nil {
+		utils.LoggerOrNop(offloader.Logger).Error("Error in reading offloaded attributes", "error", err)
+		return
+	}
+	for name, blobKey := range pointers {
+		if err := offloader.Store.Delete(ctx, blobKey); err != nil {
+			utils.LoggerOrNop(offloader.Logger).Error("Error in deleting offloaded attribute", "attribute", name, "key", blobKey, "error", err)
+		}
+	}
+}
+
+// Discard deletes blobs written by an Offload whose item never made it into the table.
+func (offloader *LargeAttributeOffloader) Discard(ctx context.Context, blobKeys []string) {
+	for _, blobKey := range blobKeys {
+		if err := offloader.Store.Delete(ctx, blobKey); err != nil {
+			utils.LoggerOrNop(offloader.Logger).Error("Error in deleting an unused blob", "key", blobKey, "error", err)
+		}
+	}
+}
+
+// IsOffloaded reports whether the attribute name of item is kept in the BlobStore.
+func IsOffloaded(item map[string]types.AttributeValue, name string) bool {
+	pointers, ok := item[OffloadedAttribute].(*types.AttributeValueMemberM)
+	if !ok {
+		return false
+	}
+	_, offloaded := pointers.Value[name]
+	return offloaded
+}
+
+func (offloader *LargeAttributeOffloader) blobKey(tableName string, item map[string]types.AttributeValue, attribute string) (string, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s/%s/%s/%s",
+		url.PathEscape(tableName),
+		url.PathEscape(converter.ToString("partition_key", item)),
+		url.PathEscape(converter.ToString("range_key", item)),
+		url.PathEscape(attribute),
+		hex.EncodeToString(suffix),
+	), nil
+}
+
+// offloadedPointers maps attribute names to blob keys.
+func offloadedPointers(item map[string]types.AttributeValue) (map[string]string, error) {
+	value, found := item[OffloadedAttribute]
+	if !found {
+		return nil, nil
+	}
+	pointers, ok := value.(*types.AttributeValueMemberM)
+	if !ok {
+		return nil, errors.New("offloaded attributes are not a map")
+	}
+	blobKeys := make(map[string]string, len(pointers.Value))
+	for name, pointer := range pointers.Value {
+		fields, ok := pointer.(*types.AttributeValueMemberM)
+		if !ok {
+			return nil, fmt.Errorf("pointer of offloaded attribute %s is not a map", name)
+		}
+		blobKey, ok := fields.Value[BlobKeyAttribute].(*types.AttributeValueMemberS)
+		if !ok {
+			return nil, fmt.Errorf("pointer of offloaded attribute %s has no blob key", name)
+		}
+		blobKeys[name] = blobKey.Value
+	}
+	return blobKeys, nil
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nicholaspark09/awsgorocket/blobstore"
+	"strings"
+	"testing"
+)
+
+// failingBlobStore fails every Put after the first allowedPuts.
+type failingBlobStore struct {
+	blobstore.BlobStore
+	allowedPuts int
+}
+
+func (store *failingBlobStore) Put(ctx context.Context, key string, data []byte) error {
+	if store.allowedPuts == 0 {
+		return errors.New("store is unavailable")
+	}
+	store.allowedPuts--
+	return store.BlobStore.Put(ctx, key, data)
+}
+
+func largeItem() map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"partition_key": &types.AttributeValueMemberS{Value: "customer#1"},
+		"range_key":     &types.AttributeValueMemberS{Value: "profile"},
+		"biography":     &types.AttributeValueMemberS{Value: strings.Repeat("a", 200)},
+		"history":       &types.AttributeValueMemberS{Value: strings.Repeat("b", 200)},
+		"name":          &types.AttributeValueMemberS{Value: "Jordan"},
+	}
+}
+
+func TestOffloadAndRehydrate(t *testing.T) {
+	ctx := context.Background()
+	offloader := ProvideLargeAttributeOffloader(blobstore.ProvideFileBlobStore(t.TempDir()), 100)
+	item := largeItem()
+	written, err := offloader.Offload(ctx, "table", item)
+	if err != nil {
+		t.Fatalf("Offload: %v", err)
+	}
+	if len(written) != 2 {
+		t.Fatalf("Offload wrote %d blobs, want 2", len(written))
+	}
+	if _, found := item["biography"]; found {
+		t.Fatal("offloaded attribute is still in the item")
+	}
+	if !IsOffloaded(item, "biography") || !IsOffloaded(item, "history") || IsOffloaded(item, "name") {
+		t.Fatalf("unexpected offloaded attributes: %#v", item[OffloadedAttribute])
+	}
+	if err := offloader.Rehydrate(ctx, item); err != nil {
+		t.Fatalf("Rehydrate: %v", err)
+	}
+	want := largeItem()
+	for name, value := range want {
+		if got := item[name].(*types.AttributeValueMemberS).Value; got != value.(*types.AttributeValueMemberS).Value {
+			t.Fatalf("%s = %q after Rehydrate", name, got)
+		}
+	}
+	if _, found := item[OffloadedAttribute]; found {
+		t.Fatal("Rehydrate left the pointers in the item")
+	}
+}
+
+func TestRehydrateIgnoresUserPointerLookalikes(t *testing.T) {
+	offloader := ProvideLargeAttributeOffloader(blobstore.ProvideFileBlobStore(t.TempDir()), 100)
+	lookalike := &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+		BlobKeyAttribute:  &types.AttributeValueMemberS{Value: "not/a/blob"},
+		BlobSizeAttribute: &types.AttributeValueMemberN{Value: "4"},
+	}}
+	item := map[string]types.AttributeValue{"settings": lookalike}
+	if err := offloader.Rehydrate(context.Background(), item); err != nil {
+		t.Fatalf("Rehydrate: %v", err)
+	}
+	if item["settings"] != lookalike {
+		t.Fatal("a user map was treated as a blob pointer")
+	}
+}
+
+func TestOffloadRejectsReservedAttribute(t *testing.T) {
+	offloader := ProvideLargeAttributeOffloader(blobstore.ProvideFileBlobStore(t.TempDir()), 100)
+	item := largeItem()
+	item[OffloadedAttribute] = &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{}}
+	if _, err := offloader.Offload(context.Background(), "table", item); err == nil {
+		t.Fatal("expected Offload to reject an item that sets the reserved attribute")
+	}
+}
+
+func TestOffloadDiscardsBlobsWhenItFails(t *testing.T) {
+	ctx := context.Background()
+	files := blobstore.ProvideFileBlobStore(t.TempDir())
+	offloader := ProvideLargeAttributeOffloader(&failingBlobStore{BlobStore: files, allowedPuts: 1}, 100)
+	item := largeItem()
+	if _, err := offloader.Offload(ctx, "table", item); err == nil {
+		t.Fatal("expected Offload to fail")
+	}
+	if _, found := item[OffloadedAttribute]; found {
+		t.Fatal("a failed Offload changed the item")
+	}
+
+	probe := ProvideLargeAttributeOffloader(files, 100)
+	written, err := probe.Offload(ctx, "table", largeItem())
+	if err != nil {
+		t.Fatalf("Offload: %v", err)
+	}
+	probe.Discard(ctx, written)
+	for _, blobKey := range written {
+		if _, err := files.Get(ctx, blobKey); !errors.Is(err, blobstore.ErrBlobNotFound) {
+			t.Fatalf("blob %s survived Discard", blobKey)
+		}
+	}
+}
+
+func TestOffloadUsesNewKeysForEveryWrite(t *testing.T) {
+	ctx := context.Background()
+	files := blobstore.ProvideFileBlobStore(t.TempDir())
+	offloader := ProvideLargeAttributeOffloader(files, 100)
+	first := largeItem()
+	if _, err := offloader.Offload(ctx, "table", first); err != nil {
+		t.Fatalf("Offload: %v", err)
+	}
+	second := largeItem()
+	if _, err := offloader.Offload(ctx, "table", second); err != nil {
+		t.Fatalf("Offload: %v", err)
+	}
+	offloader.Remove(ctx, first)
+	if err := offloader.Rehydrate(ctx, second); err != nil {
+		t.Fatalf("removing the replaced item's blobs broke the new item: %v", err)
+	}
+	if err := offloader.Rehydrate(ctx, first); !errors.Is(err, blobstore.ErrBlobNotFound) {
+		t.Fatalf("Rehydrate of the removed item = %v, want ErrBlobNotFound", err)
+	}
+}
@@ -0,0 +1,91 @@
+package featureflags
+
+import (
+	"fmt"
+	"github.com/nicholaspark09/awsgorocket/config"
+	"strconv"
+	"strings"
+)
+
+const defaultConfigPrefix = "FLAG_"
+
+// ConfigFlagStore reads flags from a ConfigRepositoryContract. For a flag named "new-checkout" and the
+// default prefix it reads:
+//
+//	FLAG_NEW_CHECKOUT=true
+//	FLAG_NEW_CHECKOUT_PERCENTAGE=25
+//	FLAG_NEW_CHECKOUT_ALLOW=user-1,user-2
+//	FLAG_NEW_CHECKOUT_DENY=user-3
+//	FLAG_NEW_CHECKOUT_VARIANTS=control:1,blue:1=#0000ff
+//
+// Variants are name:weight pairs with an optional =value.
+type ConfigFlagStore struct {
+	repository config.ConfigRepositoryContract
+	prefix     string
+}
+
+func ProvideConfigFlagStore(repository config.ConfigRepositoryContract, prefix string) *ConfigFlagStore {
+	if len(prefix) == 0 {
+		prefix = defaultConfigPrefix
+	}
+	return &ConfigFlagStore{repository: repository, prefix: prefix}
+}
+
+func (store *ConfigFlagStore) GetFlag(name string) (*Flag, bool, error) {
+	snapshot := config.SnapshotOf(store.repository)
+	key := store.prefix + configKey(name)
+	if _, _, found := snapshot.Lookup(key); !found {
+		return nil, false, nil
+	}
+	flag := &Flag{Name: name}
+	var err error
+	if flag.Enabled, err = snapshot.GetBool(key, false); err != nil {
+		return nil, true, err
+	}
+	if flag.Percentage, err = snapshot.GetFloat(key+"_PERCENTAGE", 100); err != nil {
+		return nil, true, err
+	}
+	if flag.Allow, err = snapshot.GetStringSlice(key+"_ALLOW", nil); err != nil {
+		return nil, true, err
+	}
+	if flag.Deny, err = snapshot.GetStringSlice(key+"_DENY", nil); err != nil {
+		return nil, true, err
+	}
+	variants, err := snapshot.GetStringSlice(key+"_VARIANTS", nil)
+	if err != nil {
+		return nil, true, err
+	}
+	for _, variant := range variants {
+		parsed, err := parseVariant(variant)
+		if err != nil {
+			return nil, true, fmt.Errorf("flag %s: %w", name, err)
+		}
+		flag.Variants = append(flag.Variants, parsed)
+	}
+	return flag, true, nil
+}
+
+// configKey turns "new-checkout" or "newCheckout.v2" into NEW_CHECKOUT / NEWCHECKOUT_V2.
+func configKey(name string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace(name))
+}
+
+func parseVariant(raw string) (Variant, error) {
+	spec, value, _ := strings.Cut(raw, "=")
+	name, weight, found := strings.Cut(spec, ":")
+	variant := Variant{Name: strings.TrimSpace(name), Weight: 1, Value: value}
+	if len(value) == 0 {
+		variant.Value = variant.Name
+	}
+	if found {
+		parsed, err := strconv.Atoi(strings.TrimSpace(weight))
+		if err != nil || parsed < 0 {
+			return Variant{}, fmt.Errorf("invalid variant weight: %s", raw)
+		}
+		variant.Weight = parsed
+	}
+	if len(variant.Name) == 0 {
+		return Variant{}, fmt.Errorf("invalid variant: %s", raw)
+	}
+	return variant, nil
+}
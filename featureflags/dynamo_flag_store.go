@@ -0,0 +1,176 @@
+package featureflags
+
+import (
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nicholaspark09/awsgorocket/converter"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultFlagPartition is the partition_key the flags live under when none is given.
+const DefaultFlagPartition = "feature_flags"
+
+// maxErrorTtl bounds how long a failed lookup is cached, so a throttled or unreachable table is not hit
+// on every evaluation but a recovered one is picked up quickly.
+const maxErrorTtl = 5 * time.Second
+
+// FlagFetcher is the part of database.DatabaseHelper[Flag] the store needs.
+type FlagFetcher interface {
+	Fetch(partitionKey string, rangeKey string) (*Flag, *error)
+}
+
+type cachedFlag struct {
+	flag    *Flag
+	found   bool
+	err     error
+	expires time.Time
+}
+
+// DynamoFlagStore reads flags through a DatabaseHelper, one item per flag with the flag name as range_key.
+// Lookups are cached for ttl so evaluating a flag does not cost a read every time. Failed lookups are
+// cached too, for ttl but at most maxErrorTtl.
+type DynamoFlagStore struct {
+	helper       FlagFetcher
+	partitionKey string
+	ttl          time.Duration
+	mutex        sync.Mutex
+	cache        map[string]cachedFlag
+}
+
+// ProvideDynamoFlagStore expects helper, usually a *database.DatabaseHelper[Flag], to use a FlagConverter
+// for the same partitionKey.
+func ProvideDynamoFlagStore(helper FlagFetcher, partitionKey string, ttl time.Duration) *DynamoFlagStore {
+	if len(partitionKey) == 0 {
+		partitionKey = DefaultFlagPartition
+	}
+	return &DynamoFlagStore{
+		helper:       helper,
+		partitionKey: partitionKey,
+		ttl:          ttl,
+		cache:        map[string]cachedFlag{},
+	}
+}
+
+func (store *DynamoFlagStore) GetFlag(name string) (*Flag, bool, error) {
+	store.mutex.Lock()
+	cached, ok := store.cache[name]
+	store.mutex.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.flag, cached.found, cached.err
+	}
+	flag, err := store.helper.Fetch(store.partitionKey, name)
+	if err != nil {
+		store.mutex.Lock()
+		store.cache[name] = cachedFlag{err: *err, expires: time.Now().Add(min(store.ttl, maxErrorTtl))}
+		store.mutex.Unlock()
+		return nil, false, *err
+	}
+	store.mutex.Lock()
+	store.cache[name] = cachedFlag{flag: flag, found: flag != nil, expires: time.Now().Add(store.ttl)}
+	store.mutex.Unlock()
+	return flag, flag != nil, nil
+}
+
+// Invalidate drops every cached flag so the next evaluation reads the table again.
+func (store *DynamoFlagStore) Invalidate() {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.cache = map[string]cachedFlag{}
+}
+
+// FlagConverter stores a Flag as partition_key, range_key (the name), enabled, percentage,
+// allow, deny and variants attributes.
+type FlagConverter struct {
+	partitionKey string
+}
+
+func ProvideFlagConverter(partitionKey string) converter.ModelConverterContract[Flag] {
+	if len(partitionKey) == 0 {
+		partitionKey = DefaultFlagPartition
+	}
+	return &FlagConverter{partitionKey: partitionKey}
+}
+
+func (flagConverter *FlagConverter) ConvertToItem(data *Flag) (map[string]types.AttributeValue, *error) {
+	if data == nil || len(data.Name) == 0 {
+		err := errors.New("a flag needs a name")
+		return nil, &err
+	}
+	item := map[string]types.AttributeValue{
+		"partition_key": &types.AttributeValueMemberS{Value: flagConverter.partitionKey},
+		"range_key":     &types.AttributeValueMemberS{Value: data.Name},
+		"enabled":       &types.AttributeValueMemberBOOL{Value: data.Enabled},
+		"percentage":    &types.AttributeValueMemberN{Value: strconv.FormatFloat(data.Percentage, 'f', -1, 64)},
+		"allow":         stringList(data.Allow),
+		"deny":          stringList(data.Deny),
+	}
+	variants := make([]types.AttributeValue, 0, len(data.Variants))
+	for _, variant := range data.Variants {
+		variants = append(variants, &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"name":   &types.AttributeValueMemberS{Value: variant.Name},
+			"weight": &types.AttributeValueMemberN{Value: strconv.Itoa(variant.Weight)},
+			"value":  &types.AttributeValueMemberS{Value: variant.Value},
+		}})
+	}
+	item["variants"] = &types.AttributeValueMemberL{Value: variants}
+	return item, nil
+}
+
+func (flagConverter *FlagConverter) ConvertToModel(item map[string]types.AttributeValue) (*Flag, *error) {
+	flag := &Flag{
+		Name:       converter.ToString("range_key", item),
+		Enabled:    converter.ToBool("enabled", item),
+		Percentage: 100,
+		Allow:      fromStringList(item["allow"]),
+		Deny:       fromStringList(item["deny"]),
+	}
+	if percentage, ok := item["percentage"].(*types.AttributeValueMemberN); ok {
+		parsed, err := strconv.ParseFloat(percentage.Value, 64)
+		if err != nil {
+			return nil, &err
+		}
+		flag.Percentage = parsed
+	}
+	if variants, ok := item["variants"].(*types.AttributeValueMemberL); ok {
+		for _, value := range variants.Value {
+			variant, ok := value.(*types.AttributeValueMemberM)
+			if !ok {
+				continue
+			}
+			weight := converter.ToInt("weight", variant.Value)
+			if weight < 0 {
+				weight = 0
+			}
+			flag.Variants = append(flag.Variants, Variant{
+				Name:   converter.ToString("name", variant.Value),
+				Weight: weight,
+				Value:  converter.ToString("value", variant.Value),
+			})
+		}
+	}
+	return flag, nil
+}
+
+func stringList(values []string) types.AttributeValue {
+	list := make([]types.AttributeValue, 0, len(values))
+	for _, value := range values {
+		list = append(list, &types.AttributeValueMemberS{Value: value})
+	}
+	return &types.AttributeValueMemberL{Value: list}
+}
+
+func fromStringList(value types.AttributeValue) []string {
+	list, ok := value.(*types.AttributeValueMemberL)
+	if !ok {
+		return nil
+	}
+	var values []string
+	for _, element := range list.Value {
+		if text, ok := element.(*types.AttributeValueMemberS); ok {
+			values = append(values, text.Value)
+		}
+	}
+	return values
+}
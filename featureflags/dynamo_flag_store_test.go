@@ -0,0 +1,58 @@
+package featureflags
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type countingFetcher struct {
+	flag  *Flag
+	err   error
+	calls int
+}
+
+func (fetcher *countingFetcher) Fetch(partitionKey string, rangeKey string) (*Flag, *error) {
+	fetcher.calls++
+	if fetcher.err != nil {
+		return nil, &fetcher.err
+	}
+	return fetcher.flag, nil
+}
+
+func TestDynamoFlagStoreCachesFlags(t *testing.T) {
+	fetcher := &countingFetcher{flag: &Flag{Name: "checkout", Enabled: true}}
+	store := ProvideDynamoFlagStore(fetcher, "", time.Minute)
+	for i := 0; i < 3; i++ {
+		if flag, found, err := store.GetFlag("checkout"); err != nil || !found || !flag.Enabled {
+			t.Fatalf("GetFlag = %v, %v, %v", flag, found, err)
+		}
+	}
+	if fetcher.calls != 1 {
+		t.Fatalf("fetched %d times, want 1", fetcher.calls)
+	}
+}
+
+func TestDynamoFlagStoreCachesErrorsBriefly(t *testing.T) {
+	fetcher := &countingFetcher{err: errors.New("throttled")}
+	store := ProvideDynamoFlagStore(fetcher, "", time.Minute)
+	for i := 0; i < 3; i++ {
+		if _, _, err := store.GetFlag("checkout"); err == nil {
+			t.Fatal("expected the lookup error")
+		}
+	}
+	if fetcher.calls != 1 {
+		t.Fatalf("fetched %d times, want the error to be cached", fetcher.calls)
+	}
+	cached := store.cache["checkout"]
+	if ttl := time.Until(cached.expires); ttl > maxErrorTtl {
+		t.Fatalf("error cached for %s, want at most %s", ttl, maxErrorTtl)
+	}
+
+	fetcher.err = nil
+	fetcher.flag = &Flag{Name: "checkout"}
+	store.Invalidate()
+	if _, found, err := store.GetFlag("checkout"); err != nil || !found {
+		t.Fatalf("GetFlag after recovery = %v, %v", found, err)
+	}
+}
@@ -0,0 +1,193 @@
+package featureflags
+
+import (
+	"github.com/nicholaspark09/awsgorocket/metrics"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"hash/fnv"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+)
+
+// DefaultMetricSampleRate is the share of evaluations that emit the FeatureFlagEvaluation metric.
+const DefaultMetricSampleRate = 0.01
+
+// Reasons reported in an Evaluation and as the Reason dimension of the FeatureFlagEvaluation metric.
+const (
+	ReasonMissing  = "Missing"
+	ReasonError    = "Error"
+	ReasonDenied   = "Denied"
+	ReasonAllowed  = "Allowed"
+	ReasonDisabled = "Disabled"
+	ReasonRollout  = "Rollout"
+	ReasonExcluded = "Excluded"
+	// ReasonAnonymous is an empty user ID on a flag rolled out to less than 100%.
+	ReasonAnonymous = "Anonymous"
+)
+
+type Evaluation struct {
+	Flag    string
+	Enabled bool
+	// Variant and Value are empty unless the flag is enabled and has variants.
+	Variant string
+	Value   string
+	Reason  string
+}
+
+// FeatureFlags evaluates flags from the first store that has them. The same flag and user ID
+// always land in the same rollout bucket and variant, on every instance. An empty user ID can't be
+// bucketed, so it only sees flags rolled out to 100%, and never gets a variant.
+type FeatureFlags struct {
+	stores           []FlagStore
+	metricsManager   metrics.MetricsManagerContract
+	metricSampleRate float64
+	logger           *slog.Logger
+}
+
+// ProvideFeatureFlags takes stores in priority order, e.g. a ConfigFlagStore for local overrides
+// ahead of a DynamoFlagStore. metricsManager may be nil.
+func ProvideFeatureFlags(metricsManager metrics.MetricsManagerContract, stores ...FlagStore) *FeatureFlags {
+	return &FeatureFlags{stores: stores, metricsManager: metricsManager, metricSampleRate: DefaultMetricSampleRate}
+}
+
+// WithMetricSampleRate sets the share of evaluations, from 0 to 1, that emit the FeatureFlagEvaluation
+// metric. Each sampled evaluation counts 1/rate, so the totals stay right; use 1 to count every one.
+func (flags *FeatureFlags) WithMetricSampleRate(rate float64) *FeatureFlags {
+	flags.metricSampleRate = rate
+	return flags
+}
+
+// WithLogger sets the logger that hears about store and parse errors; nothing is logged without one.
+func (flags *FeatureFlags) WithLogger(logger *slog.Logger) *FeatureFlags {
+	flags.logger = logger
+	return flags
+}
+
+func (flags *FeatureFlags) IsEnabled(name string, userId string) bool {
+	return flags.Evaluate(name, userId).Enabled
+}
+
+// Variant returns the variant name for userId, or fallback when the flag is off or has no variants.
+func (flags *FeatureFlags) Variant(name string, userId string, fallback string) string {
+	evaluation := flags.Evaluate(name, userId)
+	if len(evaluation.Variant) == 0 {
+		return fallback
+	}
+	return evaluation.Variant
+}
+
+func (flags *FeatureFlags) String(name string, userId string, fallback string) string {
+	evaluation := flags.Evaluate(name, userId)
+	if len(evaluation.Variant) == 0 {
+		return fallback
+	}
+	return evaluation.Value
+}
+
+func (flags *FeatureFlags) Int(name string, userId string, fallback int) int {
+	return typedValue(flags, name, userId, fallback, strconv.Atoi)
+}
+
+func (flags *FeatureFlags) Float(name string, userId string, fallback float64) float64 {
+	return typedValue(flags, name, userId, fallback, func(value string) (float64, error) {
+		return strconv.ParseFloat(value, 64)
+	})
+}
+
+func (flags *FeatureFlags) Bool(name string, userId string, fallback bool) bool {
+	return typedValue(flags, name, userId, fallback, strconv.ParseBool)
+}
+
+func (flags *FeatureFlags) Evaluate(name string, userId string) Evaluation {
+	evaluation := flags.evaluate(name, userId)
+	if flags.metricsManager != nil && flags.metricSampleRate > 0 && rand.Float64() < flags.metricSampleRate {
+		metrics.Counter(flags.metricsManager, "FeatureFlagEvaluation", 1/flags.metricSampleRate, metrics.Dim("Flag", name), metrics.Dim("Reason", evaluation.Reason))
+	}
+	return evaluation
+}
+
+func (flags *FeatureFlags) evaluate(name string, userId string) Evaluation {
+	flag, err := flags.lookup(name)
+	if err != nil {
+		utils.LoggerOrNop(flags.logger).Warn("Error in reading feature flag", "flag", name, "error", err)
+		return Evaluation{Flag: name, Reason: ReasonError}
+	}
+	if flag == nil {
+		return Evaluation{Flag: name, Reason: ReasonMissing}
+	}
+	evaluation := Evaluation{Flag: name}
+	switch {
+	case len(userId) > 0 && slices.Contains(flag.Deny, userId):
+		evaluation.Reason = ReasonDenied
+	case len(userId) > 0 && slices.Contains(flag.Allow, userId):
+		evaluation.Enabled, evaluation.Reason = true, ReasonAllowed
+	case !flag.Enabled:
+		evaluation.Reason = ReasonDisabled
+	case len(userId) == 0 && flag.Percentage < 100:
+		evaluation.Reason = ReasonAnonymous
+	case len(userId) == 0 || bucket(name, "rollout", userId) < flag.Percentage:
+		evaluation.Enabled, evaluation.Reason = true, ReasonRollout
+	default:
+		evaluation.Reason = ReasonExcluded
+	}
+	if evaluation.Enabled && len(userId) > 0 {
+		if variant, ok := pickVariant(name, userId, flag.Variants); ok {
+			evaluation.Variant, evaluation.Value = variant.Name, variant.Value
+		}
+	}
+	return evaluation
+}
+
+func (flags *FeatureFlags) lookup(name string) (*Flag, error) {
+	for _, store := range flags.stores {
+		flag, found, err := store.GetFlag(name)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return flag, nil
+		}
+	}
+	return nil, nil
+}
+
+// bucket hashes the flag, a salt and the user ID to a stable point in [0, 100). Salting rollout and
+// variant selection separately keeps them independent of each other.
+func bucket(name string, salt string, userId string) float64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(name + "\x00" + salt + "\x00" + userId))
+	return float64(hash.Sum64()%10000) / 100
+}
+
+func pickVariant(name string, userId string, variants []Variant) (Variant, bool) {
+	total := 0
+	for _, variant := range variants {
+		total += variant.Weight
+	}
+	if total == 0 {
+		return Variant{}, false
+	}
+	point := bucket(name, "variant", userId) / 100 * float64(total)
+	cumulative := 0
+	for _, variant := range variants {
+		cumulative += variant.Weight
+		if point < float64(cumulative) {
+			return variant, true
+		}
+	}
+	return variants[len(variants)-1], true
+}
+
+func typedValue[T any](flags *FeatureFlags, name string, userId string, fallback T, parse func(string) (T, error)) T {
+	evaluation := flags.Evaluate(name, userId)
+	if len(evaluation.Variant) == 0 {
+		return fallback
+	}
+	value, err := parse(evaluation.Value)
+	if err != nil {
+		utils.LoggerOrNop(flags.logger).Warn("Error in parsing feature flag variant", "flag", name, "variant", evaluation.Variant, "error", err)
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,188 @@
+package featureflags
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nicholaspark09/awsgorocket/config"
+	"math"
+	"reflect"
+	"testing"
+)
+
+type staticStore map[string]*Flag
+
+func (store staticStore) GetFlag(name string) (*Flag, bool, error) {
+	flag, found := store[name]
+	return flag, found, nil
+}
+
+type failingStore struct{}
+
+func (failingStore) GetFlag(name string) (*Flag, bool, error) {
+	return nil, false, errors.New("throttled")
+}
+
+func flagsWith(flag *Flag) *FeatureFlags {
+	return ProvideFeatureFlags(nil, staticStore{flag.Name: flag})
+}
+
+const users = 10000
+
+func TestRolloutDistribution(t *testing.T) {
+	flags := flagsWith(&Flag{Name: "checkout", Enabled: true, Percentage: 25})
+	enabled := 0
+	for i := 0; i < users; i++ {
+		if flags.IsEnabled("checkout", fmt.Sprintf("user-%d", i)) {
+			enabled++
+		}
+	}
+	if share := float64(enabled) / users * 100; math.Abs(share-25) > 2 {
+		t.Fatalf("%.1f%% of users enabled, want about 25%%", share)
+	}
+}
+
+func TestRolloutIsStable(t *testing.T) {
+	flag := &Flag{Name: "checkout", Enabled: true, Percentage: 50, Variants: []Variant{{Name: "a", Weight: 1}, {Name: "b", Weight: 1}}}
+	first, second := flagsWith(flag), flagsWith(flag)
+	for i := 0; i < 1000; i++ {
+		userId := fmt.Sprintf("user-%d", i)
+		if evaluation := first.Evaluate("checkout", userId); evaluation != second.Evaluate("checkout", userId) || evaluation != first.Evaluate("checkout", userId) {
+			t.Fatalf("%s got different answers from the same flag", userId)
+		}
+	}
+}
+
+func TestRolloutGrowsWithPercentage(t *testing.T) {
+	flag := &Flag{Name: "checkout", Enabled: true, Percentage: 10}
+	flags := flagsWith(flag)
+	var early []string
+	for i := 0; i < 1000; i++ {
+		if userId := fmt.Sprintf("user-%d", i); flags.IsEnabled("checkout", userId) {
+			early = append(early, userId)
+		}
+	}
+	flag.Percentage = 50
+	for _, userId := range early {
+		if !flags.IsEnabled("checkout", userId) {
+			t.Fatalf("%s lost the flag when the rollout grew", userId)
+		}
+	}
+}
+
+func TestVariantWeights(t *testing.T) {
+	flags := flagsWith(&Flag{Name: "color", Enabled: true, Percentage: 100, Variants: []Variant{
+		{Name: "red", Weight: 1, Value: "#ff0000"},
+		{Name: "green", Weight: 1, Value: "#00ff00"},
+		{Name: "blue", Weight: 2, Value: "#0000ff"},
+	}})
+	counts := map[string]int{}
+	for i := 0; i < users; i++ {
+		counts[flags.Variant("color", fmt.Sprintf("user-%d", i), "none")]++
+	}
+	for name, want := range map[string]float64{"red": 25, "green": 25, "blue": 50} {
+		if share := float64(counts[name]) / users * 100; math.Abs(share-want) > 2 {
+			t.Fatalf("variant %s has %.1f%% of users, want about %.0f%%: %v", name, share, want, counts)
+		}
+	}
+	if value := flags.String("color", "user-1", "none"); value[0] != '#' {
+		t.Fatalf("String = %q, want the variant value", value)
+	}
+}
+
+func TestTargetingRules(t *testing.T) {
+	flags := flagsWith(&Flag{Name: "beta", Enabled: false, Allow: []string{"alice", "mallory"}, Deny: []string{"mallory"}})
+	for userId, want := range map[string]string{"alice": ReasonAllowed, "mallory": ReasonDenied, "bob": ReasonDisabled} {
+		if evaluation := flags.Evaluate("beta", userId); evaluation.Reason != want || evaluation.Enabled != (want == ReasonAllowed) {
+			t.Fatalf("%s: evaluation = %+v, want %s", userId, evaluation, want)
+		}
+	}
+	everyone := flagsWith(&Flag{Name: "beta", Enabled: true, Percentage: 100, Deny: []string{"mallory"}})
+	if everyone.IsEnabled("beta", "mallory") {
+		t.Fatal("a denied user saw a flag rolled out to everyone")
+	}
+}
+
+func TestAnonymousUsers(t *testing.T) {
+	partial := flagsWith(&Flag{Name: "checkout", Enabled: true, Percentage: 99.99})
+	if evaluation := partial.Evaluate("checkout", ""); evaluation.Enabled || evaluation.Reason != ReasonAnonymous {
+		t.Fatalf("evaluation = %+v, want an anonymous caller excluded from a partial rollout", evaluation)
+	}
+	full := flagsWith(&Flag{Name: "checkout", Enabled: true, Percentage: 100, Variants: []Variant{{Name: "a", Weight: 1}}})
+	if evaluation := full.Evaluate("checkout", ""); !evaluation.Enabled || len(evaluation.Variant) > 0 {
+		t.Fatalf("evaluation = %+v, want the flag on without a variant", evaluation)
+	}
+	if variant := full.Variant("checkout", "", "control"); variant != "control" {
+		t.Fatalf("Variant = %q, want the fallback", variant)
+	}
+}
+
+func TestMissingAndFailingFlags(t *testing.T) {
+	if evaluation := ProvideFeatureFlags(nil, staticStore{}).Evaluate("checkout", "user-1"); evaluation.Enabled || evaluation.Reason != ReasonMissing {
+		t.Fatalf("evaluation = %+v, want Missing", evaluation)
+	}
+	if evaluation := ProvideFeatureFlags(nil, failingStore{}, staticStore{}).Evaluate("checkout", "user-1"); evaluation.Enabled || evaluation.Reason != ReasonError {
+		t.Fatalf("evaluation = %+v, want Error", evaluation)
+	}
+}
+
+func TestTypedValues(t *testing.T) {
+	flags := flagsWith(&Flag{Name: "limit", Enabled: true, Percentage: 100, Variants: []Variant{{Name: "high", Weight: 1, Value: "50"}}})
+	if limit := flags.Int("limit", "user-1", 10); limit != 50 {
+		t.Fatalf("Int = %d, want 50", limit)
+	}
+	if enabled := flags.Bool("limit", "user-1", true); !enabled {
+		t.Fatal("Bool of an unparsable value should return the fallback")
+	}
+}
+
+func configStore(values map[string]string) *ConfigFlagStore {
+	return ProvideConfigFlagStore(config.ProvideConfigRepository(config.ProvideMapSource("test", values)), "")
+}
+
+func TestConfigFlagStore(t *testing.T) {
+	store := configStore(map[string]string{
+		"FLAG_NEW_CHECKOUT":            "true",
+		"FLAG_NEW_CHECKOUT_PERCENTAGE": "25",
+		"FLAG_NEW_CHECKOUT_ALLOW":      "user-1,user-2",
+		"FLAG_NEW_CHECKOUT_DENY":       "user-3",
+		"FLAG_NEW_CHECKOUT_VARIANTS":   "control:1,blue:3=#0000ff",
+	})
+	flag, found, err := store.GetFlag("new-checkout")
+	if err != nil || !found {
+		t.Fatalf("GetFlag = %v, %v", found, err)
+	}
+	want := &Flag{
+		Name:       "new-checkout",
+		Enabled:    true,
+		Percentage: 25,
+		Allow:      []string{"user-1", "user-2"},
+		Deny:       []string{"user-3"},
+		Variants:   []Variant{{Name: "control", Weight: 1, Value: "control"}, {Name: "blue", Weight: 3, Value: "#0000ff"}},
+	}
+	if !reflect.DeepEqual(flag, want) {
+		t.Fatalf("flag = %+v, want %+v", flag, want)
+	}
+	if _, found, err := store.GetFlag("other"); found || err != nil {
+		t.Fatalf("GetFlag of an unset flag = %v, %v, want not found", found, err)
+	}
+}
+
+func TestConfigFlagStoreDefaultsToEveryone(t *testing.T) {
+	flag, _, err := configStore(map[string]string{"FLAG_CHECKOUT": "true"}).GetFlag("checkout")
+	if err != nil || flag.Percentage != 100 {
+		t.Fatalf("flag = %+v, %v, want a 100%% rollout", flag, err)
+	}
+}
+
+func TestConfigFlagStoreRejectsInvalidValues(t *testing.T) {
+	for _, values := range []map[string]string{
+		{"FLAG_CHECKOUT": "maybe"},
+		{"FLAG_CHECKOUT": "true", "FLAG_CHECKOUT_PERCENTAGE": "half"},
+		{"FLAG_CHECKOUT": "true", "FLAG_CHECKOUT_VARIANTS": "control:-1"},
+		{"FLAG_CHECKOUT": "true", "FLAG_CHECKOUT_VARIANTS": ":1"},
+	} {
+		if _, found, err := configStore(values).GetFlag("checkout"); !found || err == nil {
+			t.Fatalf("GetFlag with %v = %v, %v, want an error", values, found, err)
+		}
+	}
+}
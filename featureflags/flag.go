@@ -0,0 +1,25 @@
+package featureflags
+
+// Variant is one arm of a multivariate flag. Weights are relative, so 1/1/2 splits users 25/25/50.
+type Variant struct {
+	Name   string
+	Weight int
+	Value  string
+}
+
+// Flag is evaluated in order: Deny wins over Allow, Allow wins over Enabled, and Percentage limits
+// who among the rest sees the flag. Users that see it get one of the Variants when any are set.
+type Flag struct {
+	Name    string
+	Enabled bool
+	// Percentage is the share of users, from 0 to 100, that see an enabled flag.
+	Percentage float64
+	Allow      []string
+	Deny       []string
+	Variants   []Variant
+}
+
+// FlagStore finds flag definitions. found is false when the store has no flag by that name.
+type FlagStore interface {
+	GetFlag(name string) (flag *Flag, found bool, err error)
+}
@@ -0,0 +1,303 @@
+package alarms
+
+import (
+	"fmt"
+	"github.com/nicholaspark09/awsgorocket/metrics"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Defaults used when Options or an Operation leave a threshold at zero.
+const (
+	DefaultErrorRatePercent  = 5
+	DefaultLatencyP99        = time.Second
+	DefaultThrottles         = 1
+	DefaultPeriod            = time.Minute
+	DefaultEvaluationPeriods = 5
+	DefaultDatapointsToAlarm = 3
+)
+
+// Operation is one call name passed to metrics.Measure, metrics.RecordOutcome or an instrumented
+// decorator. Zero thresholds use the Options ones.
+type Operation struct {
+	Name string
+	// ErrorRatePercent alarms when Failure is more than this share of Success plus Failure.
+	ErrorRatePercent float64
+	// LatencyP99 alarms when the p99 of all calls is above it.
+	LatencyP99 time.Duration
+	// Throttles alarms when at least this many calls in a period fail with ErrorClass Throttle.
+	Throttles float64
+}
+
+type Options struct {
+	// Namespace and Dimensions must match the metrics manager's, since CloudWatch only matches a
+	// metric with exactly the same dimensions. FromMetricsOptions copies them.
+	Namespace  string
+	Dimensions []metrics.Dimension
+	// Prefix starts every alarm name and names the dashboard; it defaults to the namespace.
+	Prefix string
+	// Region is shown on the dashboard widgets. CloudFormation output uses the stack's region when empty.
+	Region string
+
+	ErrorRatePercent  float64
+	LatencyP99        time.Duration
+	Throttles         float64
+	Period            time.Duration
+	EvaluationPeriods int32
+	DatapointsToAlarm int32
+	// AlarmActions and OkActions are ARNs, e.g. SNS topics, notified when an alarm changes state.
+	AlarmActions []string
+	OkActions    []string
+}
+
+// FromMetricsOptions takes the namespace, default dimensions and region from the options a
+// MetricsManager was built with.
+func FromMetricsOptions(options metrics.MetricsOptions) Options {
+	alarmOptions := Options{Namespace: options.Namespace}
+	for _, dimension := range options.DefaultDimensions {
+		if len(dimension.Value) == 0 {
+			continue
+		}
+		alarmOptions.Dimensions = append(alarmOptions.Dimensions, dimension)
+		if dimension.Name == metrics.DimensionRegion {
+			alarmOptions.Region = dimension.Value
+		}
+	}
+	return alarmOptions
+}
+
+// Dimension, Metric, MetricStat, MetricDataQuery and Alarm use the property names of
+// AWS::CloudWatch::Alarm, so an Alarm is also its CloudFormation Properties.
+type Dimension struct {
+	Name  string `json:"Name"`
+	Value string `json:"Value"`
+}
+
+type Metric struct {
+	Namespace  string      `json:"Namespace"`
+	MetricName string      `json:"MetricName"`
+	Dimensions []Dimension `json:"Dimensions"`
+}
+
+type MetricStat struct {
+	Metric Metric `json:"Metric"`
+	Period int32  `json:"Period"`
+	Stat   string `json:"Stat"`
+}
+
+type MetricDataQuery struct {
+	Id         string      `json:"Id"`
+	Expression string      `json:"Expression,omitempty"`
+	Label      string      `json:"Label,omitempty"`
+	MetricStat *MetricStat `json:"MetricStat,omitempty"`
+	ReturnData bool        `json:"ReturnData"`
+}
+
+type Alarm struct {
+	AlarmName          string            `json:"AlarmName"`
+	AlarmDescription   string            `json:"AlarmDescription"`
+	Namespace          string            `json:"Namespace,omitempty"`
+	MetricName         string            `json:"MetricName,omitempty"`
+	Dimensions         []Dimension       `json:"Dimensions,omitempty"`
+	Statistic          string            `json:"Statistic,omitempty"`
+	ExtendedStatistic  string            `json:"ExtendedStatistic,omitempty"`
+	Period             int32             `json:"Period,omitempty"`
+	Metrics            []MetricDataQuery `json:"Metrics,omitempty"`
+	EvaluationPeriods  int32             `json:"EvaluationPeriods"`
+	DatapointsToAlarm  int32             `json:"DatapointsToAlarm"`
+	Threshold          float64           `json:"Threshold"`
+	ComparisonOperator string            `json:"ComparisonOperator"`
+	TreatMissingData   string            `json:"TreatMissingData"`
+	AlarmActions       []string          `json:"AlarmActions,omitempty"`
+	OKActions          []string          `json:"OKActions,omitempty"`
+
+	// logicalId names the alarm's CloudFormation resource.
+	logicalId string
+}
+
+// Generator builds the alarms and dashboard of the registered operations. Its output only depends on
+// the options and the set of operations, not on the order they were registered in.
+type Generator struct {
+	options    Options
+	operations map[string]Operation
+}
+
+func ProvideGenerator(options Options) *Generator {
+	if len(options.Prefix) == 0 {
+		options.Prefix = options.Namespace
+	}
+	if options.ErrorRatePercent <= 0 {
+		options.ErrorRatePercent = DefaultErrorRatePercent
+	}
+	if options.LatencyP99 <= 0 {
+		options.LatencyP99 = DefaultLatencyP99
+	}
+	if options.Throttles <= 0 {
+		options.Throttles = DefaultThrottles
+	}
+	if options.Period <= 0 {
+		options.Period = DefaultPeriod
+	}
+	if options.EvaluationPeriods <= 0 {
+		options.EvaluationPeriods = DefaultEvaluationPeriods
+	}
+	if options.DatapointsToAlarm <= 0 || options.DatapointsToAlarm > options.EvaluationPeriods {
+		options.DatapointsToAlarm = min(DefaultDatapointsToAlarm, options.EvaluationPeriods)
+	}
+	return &Generator{options: options, operations: map[string]Operation{}}
+}
+
+// Register adds operations; registering a name again replaces its thresholds.
+func (generator *Generator) Register(operations ...Operation) *Generator {
+	for _, operation := range operations {
+		if operation.ErrorRatePercent <= 0 {
+			operation.ErrorRatePercent = generator.options.ErrorRatePercent
+		}
+		if operation.LatencyP99 <= 0 {
+			operation.LatencyP99 = generator.options.LatencyP99
+		}
+		if operation.Throttles <= 0 {
+			operation.Throttles = generator.options.Throttles
+		}
+		generator.operations[operation.Name] = operation
+	}
+	return generator
+}
+
+// RegisterNames registers operations with the default thresholds.
+func (generator *Generator) RegisterNames(names ...string) *Generator {
+	for _, name := range names {
+		generator.Register(Operation{Name: name})
+	}
+	return generator
+}
+
+// Operations returns the registered operations ordered by name.
+func (generator *Generator) Operations() []Operation {
+	operations := make([]Operation, 0, len(generator.operations))
+	for _, operation := range generator.operations {
+		operations = append(operations, operation)
+	}
+	sort.Slice(operations, func(i, j int) bool {
+		return operations[i].Name < operations[j].Name
+	})
+	return operations
+}
+
+// Alarms returns an error rate, a p99 latency and a throttle alarm per operation, ordered by operation.
+func (generator *Generator) Alarms() []Alarm {
+	var alarms []Alarm
+	for _, operation := range generator.Operations() {
+		alarms = append(alarms,
+			generator.errorRateAlarm(operation),
+			generator.latencyAlarm(operation),
+			generator.throttleAlarm(operation),
+		)
+	}
+	return alarms
+}
+
+func (generator *Generator) errorRateAlarm(operation Operation) Alarm {
+	alarm := generator.alarm(operation, "ErrorRate", fmt.Sprintf("More than %g%% of %s calls failed", operation.ErrorRatePercent, operation.Name))
+	alarm.Metrics = []MetricDataQuery{
+		{Id: "success", MetricStat: generator.metricStat(metrics.MetricSuccess, "Sum", metrics.Dim(metrics.DimensionOperation, operation.Name))},
+		{Id: "failure", MetricStat: generator.metricStat(metrics.MetricFailure, "Sum", metrics.Dim(metrics.DimensionOperation, operation.Name))},
+		{
+			Id:         "error_rate",
+			Expression: "100 * FILL(failure, 0) / (FILL(success, 0) + FILL(failure, 0))",
+			Label:      operation.Name + " error rate",
+			ReturnData: true,
+		},
+	}
+	alarm.Threshold = operation.ErrorRatePercent
+	return alarm
+}
+
+func (generator *Generator) latencyAlarm(operation Operation) Alarm {
+	alarm := generator.alarm(operation, "LatencyP99", fmt.Sprintf("p99 latency of %s calls is above %s", operation.Name, operation.LatencyP99))
+	alarm.setMetric(generator.metric(metrics.MetricLatency, metrics.Dim(metrics.DimensionOperation, operation.Name)))
+	alarm.ExtendedStatistic = "p99"
+	alarm.Period = generator.period()
+	alarm.Threshold = float64(operation.LatencyP99) / float64(time.Millisecond)
+	return alarm
+}
+
+func (generator *Generator) throttleAlarm(operation Operation) Alarm {
+	alarm := generator.alarm(operation, "Throttles", fmt.Sprintf("%s calls are being throttled", operation.Name))
+	alarm.setMetric(generator.metric(metrics.MetricErrors,
+		metrics.Dim(metrics.DimensionOperation, operation.Name),
+		metrics.Dim(metrics.DimensionErrorClass, metrics.ErrorClassThrottle),
+	))
+	alarm.Statistic = "Sum"
+	alarm.Period = generator.period()
+	alarm.Threshold = operation.Throttles
+	alarm.ComparisonOperator = "GreaterThanOrEqualToThreshold"
+	return alarm
+}
+
+func (generator *Generator) alarm(operation Operation, kind string, description string) Alarm {
+	return Alarm{
+		AlarmName:          strings.Join([]string{generator.options.Prefix, operation.Name, kind}, "-"),
+		AlarmDescription:   description,
+		EvaluationPeriods:  generator.options.EvaluationPeriods,
+		DatapointsToAlarm:  generator.options.DatapointsToAlarm,
+		ComparisonOperator: "GreaterThanThreshold",
+		TreatMissingData:   "notBreaching",
+		AlarmActions:       generator.options.AlarmActions,
+		OKActions:          generator.options.OkActions,
+		logicalId:          logicalId(operation.Name, kind),
+	}
+}
+
+func (alarm *Alarm) setMetric(metric Metric) {
+	alarm.Namespace = metric.Namespace
+	alarm.MetricName = metric.MetricName
+	alarm.Dimensions = metric.Dimensions
+}
+
+func (generator *Generator) metricStat(name string, stat string, dimensions ...metrics.Dimension) *MetricStat {
+	return &MetricStat{Metric: generator.metric(name, dimensions...), Period: generator.period(), Stat: stat}
+}
+
+// metric adds the default dimensions; like the metrics manager, a dimension given here overrides a
+// default of the same name, and the result is ordered by name.
+func (generator *Generator) metric(name string, dimensions ...metrics.Dimension) Metric {
+	byName := map[string]string{}
+	for _, dimension := range append(append([]metrics.Dimension{}, generator.options.Dimensions...), dimensions...) {
+		byName[dimension.Name] = dimension.Value
+	}
+	metric := Metric{Namespace: generator.options.Namespace, MetricName: name, Dimensions: make([]Dimension, 0, len(byName))}
+	for dimensionName, value := range byName {
+		metric.Dimensions = append(metric.Dimensions, Dimension{Name: dimensionName, Value: value})
+	}
+	sort.Slice(metric.Dimensions, func(i, j int) bool {
+		return metric.Dimensions[i].Name < metric.Dimensions[j].Name
+	})
+	return metric
+}
+
+func (generator *Generator) period() int32 {
+	return int32(generator.options.Period / time.Second)
+}
+
+// logicalId turns "Orders.Fetch" and "ErrorRate" into "OrdersFetchErrorRateAlarm".
+func logicalId(parts ...string) string {
+	var builder strings.Builder
+	for _, part := range append(parts, "Alarm") {
+		upper := true
+		for _, character := range part {
+			isAlphanumeric := (character >= 'a' && character <= 'z') || (character >= 'A' && character <= 'Z') || (character >= '0' && character <= '9')
+			if !isAlphanumeric {
+				upper = true
+				continue
+			}
+			if upper && character >= 'a' && character <= 'z' {
+				character -= 'a' - 'A'
+			}
+			builder.WriteRune(character)
+			upper = false
+		}
+	}
+	return builder.String()
+}
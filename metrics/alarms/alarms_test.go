@@ -0,0 +1,119 @@
+package alarms
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/nicholaspark09/awsgorocket/metrics"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func testGenerator(region string) *Generator {
+	return ProvideGenerator(Options{
+		Namespace:    "Orders",
+		Dimensions:   []metrics.Dimension{metrics.Dim(metrics.DimensionService, "orders")},
+		Region:       region,
+		AlarmActions: []string{"arn:aws:sns:us-west-2:123456789012:alerts"},
+	}).
+		Register(Operation{Name: "Orders.Fetch", LatencyP99: 250 * time.Millisecond}).
+		RegisterNames("Users.Get")
+}
+
+func compareGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%s differs from the output; run go test -update to rewrite it\n%s", path, got)
+	}
+}
+
+func TestJSONMatchesGolden(t *testing.T) {
+	output, err := testGenerator("us-west-2").JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareGolden(t, "definitions.json", output)
+}
+
+func TestCloudFormationMatchesGolden(t *testing.T) {
+	output, err := testGenerator("").CloudFormation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareGolden(t, "cloudformation.json", output)
+}
+
+func TestOutputIgnoresRegistrationOrder(t *testing.T) {
+	first, _ := ProvideGenerator(Options{Namespace: "Orders"}).RegisterNames("Users.Get", "Orders.Fetch").JSON()
+	second, _ := ProvideGenerator(Options{Namespace: "Orders"}).RegisterNames("Orders.Fetch", "Users.Get").JSON()
+	if !bytes.Equal(first, second) {
+		t.Fatal("the output depends on the order operations were registered in")
+	}
+}
+
+type fakeCloudWatchClient struct {
+	failAlarm  string
+	alarms     []*cloudwatch.PutMetricAlarmInput
+	dashboards []*cloudwatch.PutDashboardInput
+}
+
+func (client *fakeCloudWatchClient) PutMetricAlarm(ctx context.Context, params *cloudwatch.PutMetricAlarmInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricAlarmOutput, error) {
+	client.alarms = append(client.alarms, params)
+	if aws.ToString(params.AlarmName) == client.failAlarm {
+		return nil, errors.New("access denied")
+	}
+	return &cloudwatch.PutMetricAlarmOutput{}, nil
+}
+
+func (client *fakeCloudWatchClient) PutDashboard(ctx context.Context, params *cloudwatch.PutDashboardInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutDashboardOutput, error) {
+	client.dashboards = append(client.dashboards, params)
+	return &cloudwatch.PutDashboardOutput{}, nil
+}
+
+func TestApplyPutsEveryAlarmAndTheDashboard(t *testing.T) {
+	client := &fakeCloudWatchClient{failAlarm: "Orders-Orders.Fetch-ErrorRate"}
+	generator := testGenerator("us-west-2")
+	err := generator.Apply(context.Background(), client)
+	if err == nil || !strings.Contains(err.Error(), "Orders-Orders.Fetch-ErrorRate") {
+		t.Fatalf("Apply = %v, want the failed alarm's error", err)
+	}
+	if len(client.alarms) != 6 {
+		t.Fatalf("put %d alarms, want 6 even after a failure", len(client.alarms))
+	}
+	if len(client.dashboards) != 1 || aws.ToString(client.dashboards[0].DashboardBody) != generator.Dashboard().DashboardBody {
+		t.Fatalf("dashboards = %v, want the generated one", client.dashboards)
+	}
+	latency := client.alarms[1]
+	if aws.ToString(latency.AlarmName) != "Orders-Orders.Fetch-LatencyP99" ||
+		aws.ToString(latency.MetricName) != metrics.MetricLatency ||
+		aws.ToString(latency.ExtendedStatistic) != "p99" ||
+		aws.ToFloat64(latency.Threshold) != 250 ||
+		len(latency.Dimensions) != 2 {
+		t.Fatalf("latency alarm = %+v", latency)
+	}
+	errorRate := client.alarms[0]
+	if len(errorRate.Metrics) != 3 || errorRate.MetricName != nil || errorRate.Metrics[2].Expression == nil {
+		t.Fatalf("error rate alarm = %+v, want a metric math alarm", errorRate)
+	}
+	if len(errorRate.AlarmActions) != 1 {
+		t.Fatalf("alarm actions = %v", errorRate.AlarmActions)
+	}
+}
@@ -0,0 +1,104 @@
+package alarms
+
+import (
+	"encoding/json"
+	"github.com/nicholaspark09/awsgorocket/metrics"
+	"regexp"
+)
+
+const (
+	widgetWidth  = 8
+	widgetHeight = 6
+)
+
+var dashboardNameInvalid = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+type Dashboard struct {
+	DashboardName string `json:"DashboardName"`
+	DashboardBody string `json:"DashboardBody"`
+}
+
+type widget struct {
+	Type       string         `json:"type"`
+	X          int            `json:"x"`
+	Y          int            `json:"y"`
+	Width      int            `json:"width"`
+	Height     int            `json:"height"`
+	Properties map[string]any `json:"properties"`
+}
+
+// Dashboard returns one row per operation: calls and error rate, latency percentiles and errors by
+// class, each with its alarm threshold drawn in.
+func (generator *Generator) Dashboard() Dashboard {
+	return Dashboard{
+		DashboardName: dashboardNameInvalid.ReplaceAllString(generator.options.Prefix, "-"),
+		DashboardBody: generator.dashboardBody(generator.options.Region),
+	}
+}
+
+func (generator *Generator) dashboardBody(region string) string {
+	widgets := []widget{}
+	for row, operation := range generator.Operations() {
+		y := row * widgetHeight
+		widgets = append(widgets,
+			generator.widget(region, 0, y, operation.Name+" calls and error rate", operation.ErrorRatePercent, []any{
+				generator.widgetMetric(metrics.MetricSuccess, map[string]any{"id": "success", "stat": "Sum"}, operation.Name),
+				generator.widgetMetric(metrics.MetricFailure, map[string]any{"id": "failure", "stat": "Sum"}, operation.Name),
+				[]any{map[string]any{
+					"expression": "100 * FILL(failure, 0) / (FILL(success, 0) + FILL(failure, 0))",
+					"id":         "error_rate",
+					"label":      "Error rate %",
+					"yAxis":      "right",
+				}},
+			}),
+			generator.widget(region, widgetWidth, y, operation.Name+" latency", float64(operation.LatencyP99.Milliseconds()), []any{
+				generator.widgetMetric(metrics.MetricLatency, map[string]any{"stat": "p50"}, operation.Name),
+				generator.widgetMetric(metrics.MetricLatency, map[string]any{"stat": "p90"}, operation.Name),
+				generator.widgetMetric(metrics.MetricLatency, map[string]any{"stat": "p99"}, operation.Name),
+				generator.widgetMetric(metrics.MetricSuccessLatency, map[string]any{"stat": "p99", "label": "Successes p99"}, operation.Name),
+				generator.widgetMetric(metrics.MetricFailureLatency, map[string]any{"stat": "p99", "label": "Failures p99"}, operation.Name),
+			}),
+			generator.widget(region, 2*widgetWidth, y, operation.Name+" errors", operation.Throttles, []any{
+				generator.widgetMetric(metrics.MetricErrors, map[string]any{"stat": "Sum", "label": metrics.ErrorClassThrottle}, operation.Name, metrics.DimensionErrorClass, metrics.ErrorClassThrottle),
+				generator.widgetMetric(metrics.MetricErrors, map[string]any{"stat": "Sum", "label": metrics.ErrorClassNotFound}, operation.Name, metrics.DimensionErrorClass, metrics.ErrorClassNotFound),
+				generator.widgetMetric(metrics.MetricErrors, map[string]any{"stat": "Sum", "label": metrics.ErrorClassConditionalFailure}, operation.Name, metrics.DimensionErrorClass, metrics.ErrorClassConditionalFailure),
+				generator.widgetMetric(metrics.MetricErrors, map[string]any{"stat": "Sum", "label": metrics.ErrorClassNetwork}, operation.Name, metrics.DimensionErrorClass, metrics.ErrorClassNetwork),
+				generator.widgetMetric(metrics.MetricErrors, map[string]any{"stat": "Sum", "label": metrics.ErrorClass4XX}, operation.Name, metrics.DimensionErrorClass, metrics.ErrorClass4XX),
+				generator.widgetMetric(metrics.MetricErrors, map[string]any{"stat": "Sum", "label": metrics.ErrorClass5XX}, operation.Name, metrics.DimensionErrorClass, metrics.ErrorClass5XX),
+			}),
+		)
+	}
+	body, _ := json.Marshal(map[string]any{"widgets": widgets})
+	return string(body)
+}
+
+func (generator *Generator) widget(region string, x int, y int, title string, threshold float64, widgetMetrics []any) widget {
+	properties := map[string]any{
+		"title":   title,
+		"view":    "timeSeries",
+		"stacked": false,
+		"period":  generator.period(),
+		"metrics": widgetMetrics,
+		"annotations": map[string]any{
+			"horizontal": []any{map[string]any{"label": "Alarm", "value": threshold}},
+		},
+	}
+	if len(region) > 0 {
+		properties["region"] = region
+	}
+	return widget{Type: "metric", X: x, Y: y, Width: widgetWidth, Height: widgetHeight, Properties: properties}
+}
+
+// widgetMetric is a dashboard metric row: namespace, name, dimension names and values, then options.
+func (generator *Generator) widgetMetric(name string, rendering map[string]any, operation string, extra ...string) []any {
+	dimensions := []metrics.Dimension{metrics.Dim(metrics.DimensionOperation, operation)}
+	for i := 0; i+1 < len(extra); i += 2 {
+		dimensions = append(dimensions, metrics.Dim(extra[i], extra[i+1]))
+	}
+	metric := generator.metric(name, dimensions...)
+	row := []any{metric.Namespace, metric.MetricName}
+	for _, dimension := range metric.Dimensions {
+		row = append(row, dimension.Name, dimension.Value)
+	}
+	return append(row, rendering)
+}
@@ -0,0 +1,147 @@
+package alarms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// Definitions is what JSON writes: every alarm and the dashboard.
+type Definitions struct {
+	Alarms    []Alarm   `json:"Alarms"`
+	Dashboard Dashboard `json:"Dashboard"`
+}
+
+// JSON returns the alarms and dashboard as indented JSON with a trailing newline, byte for byte the
+// same for the same options and operations, so it can be compared with a golden file.
+func (generator *Generator) JSON() ([]byte, error) {
+	return marshal(Definitions{Alarms: generator.Alarms(), Dashboard: generator.Dashboard()})
+}
+
+// CloudFormation returns a template with an AWS::CloudWatch::Alarm per alarm and an
+// AWS::CloudWatch::Dashboard, formatted like JSON. Without Options.Region the dashboard uses the
+// stack's region.
+func (generator *Generator) CloudFormation() ([]byte, error) {
+	resources := map[string]any{}
+	for _, alarm := range generator.Alarms() {
+		id := alarm.logicalId
+		for suffix := 2; resources[id] != nil; suffix++ {
+			id = fmt.Sprintf("%s%d", alarm.logicalId, suffix)
+		}
+		resources[id] = map[string]any{"Type": "AWS::CloudWatch::Alarm", "Properties": alarm}
+	}
+	dashboard := generator.Dashboard()
+	var body any = dashboard.DashboardBody
+	if len(generator.options.Region) == 0 {
+		body = map[string]string{"Fn::Sub": generator.dashboardBody("${AWS::Region}")}
+	}
+	resources["Dashboard"] = map[string]any{
+		"Type": "AWS::CloudWatch::Dashboard",
+		"Properties": map[string]any{
+			"DashboardName": dashboard.DashboardName,
+			"DashboardBody": body,
+		},
+	}
+	return marshal(map[string]any{
+		"AWSTemplateFormatVersion": "2010-09-09",
+		"Description":              "Alarms and dashboard for " + generator.options.Prefix,
+		"Resources":                resources,
+	})
+}
+
+func marshal(value any) ([]byte, error) {
+	output, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(output, '\n'), nil
+}
+
+// CloudWatchClient is the part of *cloudwatch.Client that Apply uses.
+type CloudWatchClient interface {
+	PutMetricAlarm(ctx context.Context, params *cloudwatch.PutMetricAlarmInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricAlarmOutput, error)
+	PutDashboard(ctx context.Context, params *cloudwatch.PutDashboardInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutDashboardOutput, error)
+}
+
+// Apply creates or updates every alarm and the dashboard. It carries on past failures and returns
+// them all.
+func (generator *Generator) Apply(ctx context.Context, client CloudWatchClient) error {
+	var errs []error
+	for _, alarm := range generator.Alarms() {
+		if _, err := client.PutMetricAlarm(ctx, alarm.input()); err != nil {
+			errs = append(errs, fmt.Errorf("alarm %s: %w", alarm.AlarmName, err))
+		}
+	}
+	dashboard := generator.Dashboard()
+	_, err := client.PutDashboard(ctx, &cloudwatch.PutDashboardInput{
+		DashboardName: aws.String(dashboard.DashboardName),
+		DashboardBody: aws.String(dashboard.DashboardBody),
+	})
+	if err != nil {
+		errs = append(errs, fmt.Errorf("dashboard %s: %w", dashboard.DashboardName, err))
+	}
+	return errors.Join(errs...)
+}
+
+func (alarm *Alarm) input() *cloudwatch.PutMetricAlarmInput {
+	input := &cloudwatch.PutMetricAlarmInput{
+		AlarmName:          aws.String(alarm.AlarmName),
+		AlarmDescription:   aws.String(alarm.AlarmDescription),
+		EvaluationPeriods:  aws.Int32(alarm.EvaluationPeriods),
+		DatapointsToAlarm:  aws.Int32(alarm.DatapointsToAlarm),
+		Threshold:          aws.Float64(alarm.Threshold),
+		ComparisonOperator: types.ComparisonOperator(alarm.ComparisonOperator),
+		TreatMissingData:   aws.String(alarm.TreatMissingData),
+		AlarmActions:       alarm.AlarmActions,
+		OKActions:          alarm.OKActions,
+	}
+	if len(alarm.MetricName) > 0 {
+		input.Namespace = aws.String(alarm.Namespace)
+		input.MetricName = aws.String(alarm.MetricName)
+		input.Dimensions = sdkDimensions(alarm.Dimensions)
+		input.Period = aws.Int32(alarm.Period)
+	}
+	if len(alarm.Statistic) > 0 {
+		input.Statistic = types.Statistic(alarm.Statistic)
+	}
+	if len(alarm.ExtendedStatistic) > 0 {
+		input.ExtendedStatistic = aws.String(alarm.ExtendedStatistic)
+	}
+	for _, query := range alarm.Metrics {
+		sdkQuery := types.MetricDataQuery{
+			Id:         aws.String(query.Id),
+			ReturnData: aws.Bool(query.ReturnData),
+		}
+		if len(query.Expression) > 0 {
+			sdkQuery.Expression = aws.String(query.Expression)
+		}
+		if len(query.Label) > 0 {
+			sdkQuery.Label = aws.String(query.Label)
+		}
+		if query.MetricStat != nil {
+			sdkQuery.MetricStat = &types.MetricStat{
+				Metric: &types.Metric{
+					Namespace:  aws.String(query.MetricStat.Metric.Namespace),
+					MetricName: aws.String(query.MetricStat.Metric.MetricName),
+					Dimensions: sdkDimensions(query.MetricStat.Metric.Dimensions),
+				},
+				Period: aws.Int32(query.MetricStat.Period),
+				Stat:   aws.String(query.MetricStat.Stat),
+			}
+		}
+		input.Metrics = append(input.Metrics, sdkQuery)
+	}
+	return input
+}
+
+func sdkDimensions(dimensions []Dimension) []types.Dimension {
+	converted := make([]types.Dimension, 0, len(dimensions))
+	for _, dimension := range dimensions {
+		converted = append(converted, types.Dimension{Name: aws.String(dimension.Name), Value: aws.String(dimension.Value)})
+	}
+	return converted
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/nicholaspark09/awsgorocket/config"
+)
+
+// CloudWatchLogsApi is the part of *cloudwatchlogs.Client the CloudWatchLogsClient uses.
+type CloudWatchLogsApi interface {
+	PutLogEvents(ctx context.Context, params *cloudwatchlogs.PutLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error)
+	CreateLogStream(ctx context.Context, params *cloudwatchlogs.CreateLogStreamInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error)
+}
+
+// CloudWatchLogsClient is the LogEventsClient for CloudWatch Logs. It turns the service's sequence token
+// and missing stream errors into SequenceTokenError and ErrLogStreamNotFound.
+type CloudWatchLogsClient struct {
+	client CloudWatchLogsApi
+}
+
+// ProvideCloudWatchLogsClient uses the provider's credentials and region, and the
+// config.ServiceCloudWatchLogs endpoint override when the environment sets one.
+func ProvideCloudWatchLogsClient(provider config.ConfigProvider) *CloudWatchLogsClient {
+	return ProvideCloudWatchLogsClientWithApi(cloudwatchlogs.NewFromConfig(provider.SdkConfig, func(options *cloudwatchlogs.Options) {
+		options.BaseEndpoint = provider.Environment.BaseEndpoint(config.ServiceCloudWatchLogs)
+	}))
+}
+
+func ProvideCloudWatchLogsClientWithApi(client CloudWatchLogsApi) *CloudWatchLogsClient {
+	return &CloudWatchLogsClient{client: client}
+}
+
+func (client *CloudWatchLogsClient) PutLogEvents(ctx context.Context, logGroup string, logStream string, events []InputLogEvent, sequenceToken *string) (*string, error) {
+	logEvents := make([]types.InputLogEvent, 0, len(events))
+	for _, event := range events {
+		logEvents = append(logEvents, types.InputLogEvent{
+			Timestamp: aws.Int64(event.Timestamp),
+			Message:   aws.String(event.Message),
+		})
+	}
+	output, err := client.client.PutLogEvents(ctx, &cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  aws.String(logGroup),
+		LogStreamName: aws.String(logStream),
+		LogEvents:     logEvents,
+		SequenceToken: sequenceToken,
+	})
+	if err != nil {
+		return nil, logsError(err)
+	}
+	return output.NextSequenceToken, nil
+}
+
+// CreateLogStream treats a stream that already exists as success.
+func (client *CloudWatchLogsClient) CreateLogStream(ctx context.Context, logGroup string, logStream string) error {
+	_, err := client.client.CreateLogStream(ctx, &cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(logGroup),
+		LogStreamName: aws.String(logStream),
+	})
+	var alreadyExists *types.ResourceAlreadyExistsException
+	if errors.As(err, &alreadyExists) {
+		return nil
+	}
+	return err
+}
+
+func logsError(err error) error {
+	var invalidToken *types.InvalidSequenceTokenException
+	var alreadyAccepted *types.DataAlreadyAcceptedException
+	var notFound *types.ResourceNotFoundException
+	switch {
+	case errors.As(err, &invalidToken):
+		return &SequenceTokenError{ExpectedSequenceToken: invalidToken.ExpectedSequenceToken}
+	case errors.As(err, &alreadyAccepted):
+		return &SequenceTokenError{ExpectedSequenceToken: alreadyAccepted.ExpectedSequenceToken, AlreadyAccepted: true}
+	case errors.As(err, &notFound):
+		return fmt.Errorf("%w: %w", ErrLogStreamNotFound, err)
+	}
+	return err
+}
@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeLogsApi struct {
+	putError    error
+	createError error
+	puts        []*cloudwatchlogs.PutLogEventsInput
+}
+
+func (api *fakeLogsApi) PutLogEvents(ctx context.Context, params *cloudwatchlogs.PutLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	api.puts = append(api.puts, params)
+	if api.putError != nil {
+		return nil, api.putError
+	}
+	return &cloudwatchlogs.PutLogEventsOutput{NextSequenceToken: aws.String("next")}, nil
+}
+
+func (api *fakeLogsApi) CreateLogStream(ctx context.Context, params *cloudwatchlogs.CreateLogStreamInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	return &cloudwatchlogs.CreateLogStreamOutput{}, api.createError
+}
+
+func TestCloudWatchLogsClientMapsErrors(t *testing.T) {
+	tests := map[string]struct {
+		err   error
+		check func(err error) bool
+	}{
+		"invalid sequence token": {
+			err: &types.InvalidSequenceTokenException{ExpectedSequenceToken: aws.String("expected")},
+			check: func(err error) bool {
+				var tokenError *SequenceTokenError
+				return errors.As(err, &tokenError) && !tokenError.AlreadyAccepted && aws.ToString(tokenError.ExpectedSequenceToken) == "expected"
+			},
+		},
+		"already accepted": {
+			err: &types.DataAlreadyAcceptedException{},
+			check: func(err error) bool {
+				var tokenError *SequenceTokenError
+				return errors.As(err, &tokenError) && tokenError.AlreadyAccepted
+			},
+		},
+		"missing stream": {
+			err: &types.ResourceNotFoundException{},
+			check: func(err error) bool {
+				return errors.Is(err, ErrLogStreamNotFound)
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := ProvideCloudWatchLogsClientWithApi(&fakeLogsApi{putError: test.err})
+			_, err := client.PutLogEvents(context.Background(), "group", "stream", []InputLogEvent{{Timestamp: 1, Message: "hello"}}, nil)
+			if !test.check(err) {
+				t.Fatalf("PutLogEvents error = %v", err)
+			}
+		})
+	}
+}
+
+func TestCloudWatchLogsClientIgnoresExistingStream(t *testing.T) {
+	client := ProvideCloudWatchLogsClientWithApi(&fakeLogsApi{createError: &types.ResourceAlreadyExistsException{}})
+	if err := client.CreateLogStream(context.Background(), "group", "stream"); err != nil {
+		t.Fatalf("CreateLogStream = %v, want nil for an existing stream", err)
+	}
+}
+
+func TestCloudWatchLogsSinkRequeuesFailedBatches(t *testing.T) {
+	api := &fakeLogsApi{putError: errors.New("connection reset")}
+	sink := &CloudWatchLogsSink{client: ProvideCloudWatchLogsClientWithApi(api), logGroup: "group", logStream: "stream"}
+	sink.WriteEvent(time.Now(), []byte("hello"))
+
+	if err := sink.Flush(context.Background()); err == nil {
+		t.Fatal("expected the first flush to fail")
+	}
+	api.putError = nil
+	if err := sink.Flush(context.Background()); err != nil {
+		t.Fatalf("second flush: %v", err)
+	}
+	if len(api.puts) != 2 || aws.ToString(api.puts[1].LogEvents[0].Message) != "hello" {
+		t.Fatalf("puts = %d, want the failed batch sent again", len(api.puts))
+	}
+}
+
+func TestCloudWatchLogsSinkDropsBatchesAfterMaxAttempts(t *testing.T) {
+	api := &fakeLogsApi{putError: errors.New("connection reset")}
+	sink := &CloudWatchLogsSink{client: ProvideCloudWatchLogsClientWithApi(api), logGroup: "group", logStream: "stream"}
+	sink.WriteEvent(time.Now(), []byte("hello"))
+	for i := 0; i < maxPublishAttempts+1; i++ {
+		sink.Flush(context.Background())
+	}
+	if len(api.puts) != maxPublishAttempts {
+		t.Fatalf("sent %d times, want %d", len(api.puts), maxPublishAttempts)
+	}
+}
+
+// exclusiveWriter fails the test when two writes overlap.
+type exclusiveWriter struct {
+	t       *testing.T
+	writing atomic.Bool
+	lines   atomic.Int64
+}
+
+func (writer *exclusiveWriter) Write(data []byte) (int, error) {
+	if !writer.writing.CompareAndSwap(false, true) {
+		writer.t.Error("concurrent writes to the EMF writer")
+	}
+	time.Sleep(10 * time.Microsecond)
+	writer.lines.Add(1)
+	writer.writing.Store(false)
+	return len(data), nil
+}
+
+func TestEmfMetricsAndLogsShareOneWriterLock(t *testing.T) {
+	writer := &exclusiveWriter{t: t}
+	manager := ProvideEmfMetricsManager(EmfOptions{Namespace: "Test", Writer: writer})
+	var group sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		group.Add(2)
+		go func() {
+			defer group.Done()
+			manager.SendLog("test", "hello")
+		}()
+		go func() {
+			defer group.Done()
+			manager.Counter("Requests", 1)
+			manager.Flush(context.Background())
+		}()
+	}
+	group.Wait()
+	if writer.lines.Load() < 20 {
+		t.Fatalf("wrote %d lines, want at least 20", writer.lines.Load())
+	}
+}
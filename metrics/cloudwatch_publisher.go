@@ -0,0 +1,337 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/smithy-go"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxDatumsPerRequest is the most datums a single PutMetricData call accepts.
+	MaxDatumsPerRequest = 1000
+	// MaxValuesPerDatum is the most entries a datum's Values and Counts arrays may hold.
+	MaxValuesPerDatum = 150
+	// maxRequestBytes keeps a PutMetricData payload under the 1 MB request limit, with room for headers.
+	maxRequestBytes = 1000 * 1000
+	// Estimates of the form encoding PutMetricData uses: every field is sent as
+	// "MetricData.member.N.<Field>=<value>&", and numbers take at most 24 characters.
+	encodedFieldBytes     = 48
+	encodedNumberBytes    = 24
+	defaultFlushInterval  = time.Minute
+	defaultPublishTimeout = 10 * time.Second
+	// maxPublishAttempts is how many flushes try to send a batch before it is dropped; each attempt
+	// already includes the SDK's own retries.
+	maxPublishAttempts = 3
+	// maxRequeuedDatums bounds the datums kept for the next flush while CloudWatch is unavailable.
+	maxRequeuedDatums = 10 * MaxDatumsPerRequest
+)
+
+// CloudWatchClient is the part of *cloudwatch.Client the publisher uses.
+type CloudWatchClient interface {
+	PutMetricData(ctx context.Context, params *cloudwatch.PutMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricDataOutput, error)
+}
+
+type pendingBatch struct {
+	datums   []types.MetricDatum
+	attempts int
+}
+
+type aggregate struct {
+	datum      types.MetricDatum
+	statistics types.StatisticSet
+	// sketch is set instead of statistics for datums added with Observe.
+	sketch *Sketch
+}
+
+// CloudWatchPublisher buffers datums off the request path. Datums with the same name, unit, resolution
+// and dimensions are merged into one StatisticValues set, or into a Sketch sent as Values and Counts
+// when added with Observe, and the buffer is sent every flushInterval or as soon as it holds
+// MaxDatumsPerRequest distinct series.
+//
+// A batch that fails with a retryable error is sent again by the next flushes, up to maxPublishAttempts
+// times, while at most maxRequeuedDatums are waiting; the oldest batches are dropped first.
+type CloudWatchPublisher struct {
+	client        CloudWatchClient
+	namespace     string
+	flushInterval time.Duration
+	logger        *slog.Logger
+
+	mutex      sync.Mutex
+	aggregates map[string]*aggregate
+	order      []string
+	requeued   []pendingBatch
+	full       chan struct{}
+	stop       chan struct{}
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// ProvideCloudWatchPublisher starts the background flush loop. A flushInterval of zero uses one minute.
+// logger receives background flush failures and may be nil.
+func ProvideCloudWatchPublisher(client CloudWatchClient, namespace string, flushInterval time.Duration, logger *slog.Logger) *CloudWatchPublisher {
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+	publisher := &CloudWatchPublisher{
+		client:        client,
+		namespace:     namespace,
+		flushInterval: flushInterval,
+		logger:        utils.LoggerOrNop(logger),
+		aggregates:    map[string]*aggregate{},
+		full:          make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go publisher.run()
+	return publisher
+}
+
+// Add records one observation of datum.Value under datum's name, unit and dimensions.
+func (publisher *CloudWatchPublisher) Add(datum types.MetricDatum) {
+	publisher.add(datum, false)
+}
+
+// Observe records one observation into a sketch, so CloudWatch can compute percentiles from the
+// published Values and Counts rather than only min, max, sum and count.
+func (publisher *CloudWatchPublisher) Observe(datum types.MetricDatum) {
+	publisher.add(datum, true)
+}
+
+func (publisher *CloudWatchPublisher) add(datum types.MetricDatum, distribution bool) {
+	if datum.Value == nil {
+		return
+	}
+	value := *datum.Value
+	key := aggregateKey(datum)
+	if distribution {
+		key += "\x00distribution"
+	}
+	publisher.mutex.Lock()
+	existing, ok := publisher.aggregates[key]
+	if ok && existing.sketch != nil {
+		existing.sketch.Add(value)
+	} else if ok {
+		existing.statistics.SampleCount = aws.Float64(*existing.statistics.SampleCount + 1)
+		existing.statistics.Sum = aws.Float64(*existing.statistics.Sum + value)
+		existing.statistics.Minimum = aws.Float64(min(*existing.statistics.Minimum, value))
+		existing.statistics.Maximum = aws.Float64(max(*existing.statistics.Maximum, value))
+	} else {
+		if datum.Timestamp == nil {
+			datum.Timestamp = aws.Time(time.Now().UTC())
+		}
+		datum.Value = nil
+		created := &aggregate{datum: datum}
+		if distribution {
+			created.sketch = NewSketch(DefaultRelativeAccuracy)
+			created.sketch.Add(value)
+		} else {
+			created.statistics = types.StatisticSet{
+				SampleCount: aws.Float64(1),
+				Sum:         aws.Float64(value),
+				Minimum:     aws.Float64(value),
+				Maximum:     aws.Float64(value),
+			}
+		}
+		publisher.aggregates[key] = created
+		publisher.order = append(publisher.order, key)
+	}
+	isFull := len(publisher.order) >= MaxDatumsPerRequest
+	publisher.mutex.Unlock()
+	if isFull {
+		select {
+		case publisher.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush sends the batches requeued by earlier flushes and everything buffered so far, in batches of
+// up to MaxDatumsPerRequest datums and maxRequestBytes of encoded payload.
+func (publisher *CloudWatchPublisher) Flush(ctx context.Context) error {
+	publisher.mutex.Lock()
+	datums := make([]types.MetricDatum, 0, len(publisher.order))
+	for _, key := range publisher.order {
+		buffered := publisher.aggregates[key]
+		if buffered.sketch != nil {
+			datums = append(datums, distributionDatums(buffered.datum, buffered.sketch)...)
+			continue
+		}
+		buffered.datum.StatisticValues = &buffered.statistics
+		datums = append(datums, buffered.datum)
+	}
+	batches := publisher.requeued
+	publisher.requeued = nil
+	publisher.aggregates = map[string]*aggregate{}
+	publisher.order = nil
+	publisher.mutex.Unlock()
+
+	for _, batch := range metricBatches(publisher.namespace, datums) {
+		batches = append(batches, pendingBatch{datums: batch})
+	}
+	var errs []error
+	var failed []pendingBatch
+	for _, batch := range batches {
+		_, err := publisher.client.PutMetricData(ctx, &cloudwatch.PutMetricDataInput{
+			MetricData: batch.datums,
+			Namespace:  aws.String(publisher.namespace),
+		})
+		if err == nil {
+			continue
+		}
+		errs = append(errs, err)
+		batch.attempts++
+		if batch.attempts < maxPublishAttempts && isRetryable(err) {
+			failed = append(failed, batch)
+		} else {
+			errs = append(errs, fmt.Errorf("dropped %d datums after %d attempts", len(batch.datums), batch.attempts))
+		}
+	}
+	if dropped := publisher.requeue(failed); dropped > 0 {
+		errs = append(errs, fmt.Errorf("dropped %d requeued datums over the limit of %d", dropped, maxRequeuedDatums))
+	}
+	return errors.Join(errs...)
+}
+
+// requeue keeps failed for the next flush, ahead of anything requeued meanwhile, and drops the oldest
+// batches over maxRequeuedDatums. It returns the number of datums dropped.
+func (publisher *CloudWatchPublisher) requeue(failed []pendingBatch) int {
+	if len(failed) == 0 {
+		return 0
+	}
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+	publisher.requeued = append(failed, publisher.requeued...)
+	total := 0
+	for _, batch := range publisher.requeued {
+		total += len(batch.datums)
+	}
+	dropped := 0
+	for total > maxRequeuedDatums {
+		total -= len(publisher.requeued[0].datums)
+		dropped += len(publisher.requeued[0].datums)
+		publisher.requeued = publisher.requeued[1:]
+	}
+	return dropped
+}
+
+// Close stops the background loop and flushes what is left. Call it before a Lambda invocation returns
+// or a server shuts down; datums added afterwards are only sent by an explicit Flush.
+func (publisher *CloudWatchPublisher) Close() error {
+	publisher.closeOnce.Do(func() {
+		close(publisher.stop)
+		<-publisher.done
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), defaultPublishTimeout)
+	defer cancel()
+	return publisher.Flush(ctx)
+}
+
+func (publisher *CloudWatchPublisher) run() {
+	defer close(publisher.done)
+	ticker := time.NewTicker(publisher.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-publisher.stop:
+			return
+		case <-ticker.C:
+		case <-publisher.full:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), defaultPublishTimeout)
+		if err := publisher.Flush(ctx); err != nil {
+			publisher.logger.Error("Error in sending metrics", "namespace", publisher.namespace, "error", err)
+		}
+		cancel()
+	}
+}
+
+func aggregateKey(datum types.MetricDatum) string {
+	dimensions := make([]string, 0, len(datum.Dimensions))
+	for _, dimension := range datum.Dimensions {
+		dimensions = append(dimensions, aws.ToString(dimension.Name)+"="+aws.ToString(dimension.Value))
+	}
+	sort.Strings(dimensions)
+	key := aws.ToString(datum.MetricName) + "\x00" + string(datum.Unit) + "\x00" + strings.Join(dimensions, "\x00")
+	if datum.StorageResolution != nil {
+		key += "\x00" + strconv.Itoa(int(*datum.StorageResolution))
+	}
+	return key
+}
+
+// metricBatches splits datums into requests of at most MaxDatumsPerRequest datums and maxRequestBytes.
+func metricBatches(namespace string, datums []types.MetricDatum) [][]types.MetricDatum {
+	var batches [][]types.MetricDatum
+	requestBytes := encodedFieldBytes + len(url.QueryEscape(namespace))
+	start, size := 0, requestBytes
+	for i, datum := range datums {
+		datumBytes := encodedDatumSize(datum)
+		if i > start && (size+datumBytes > maxRequestBytes || i-start >= MaxDatumsPerRequest) {
+			batches = append(batches, datums[start:i])
+			start, size = i, requestBytes
+		}
+		size += datumBytes
+	}
+	if start < len(datums) {
+		batches = append(batches, datums[start:])
+	}
+	return batches
+}
+
+// encodedDatumSize estimates the bytes datum takes in a PutMetricData request, erring on the large side.
+func encodedDatumSize(datum types.MetricDatum) int {
+	size := encodedFieldBytes + len(url.QueryEscape(aws.ToString(datum.MetricName)))
+	for _, dimension := range datum.Dimensions {
+		size += 2*encodedFieldBytes + len(url.QueryEscape(aws.ToString(dimension.Name))) + len(url.QueryEscape(aws.ToString(dimension.Value)))
+	}
+	numbers := len(datum.Values) + len(datum.Counts)
+	if datum.Value != nil {
+		numbers++
+	}
+	if datum.StatisticValues != nil {
+		numbers += 4
+	}
+	// Unit, Timestamp and StorageResolution.
+	numbers += 3
+	return size + numbers*(encodedFieldBytes+encodedNumberBytes)
+}
+
+// distributionDatums splits a sketch into datums of at most MaxValuesPerDatum values each;
+// CloudWatch merges datums of the same series.
+func distributionDatums(datum types.MetricDatum, sketch *Sketch) []types.MetricDatum {
+	values, counts := sketch.Buckets()
+	datums := make([]types.MetricDatum, 0, len(values)/MaxValuesPerDatum+1)
+	for start := 0; start < len(values); start += MaxValuesPerDatum {
+		end := min(start+MaxValuesPerDatum, len(values))
+		chunk := datum
+		chunk.Values = values[start:end]
+		chunk.Counts = counts[start:end]
+		datums = append(datums, chunk)
+	}
+	return datums
+}
+
+// isRetryable reports whether a failed request may succeed when sent again: throttling, server faults
+// and transport errors are, a request the service rejected as invalid is not.
+func isRetryable(err error) bool {
+	var apiError smithy.APIError
+	if !errors.As(err, &apiError) {
+		return true
+	}
+	if _, throttled := retry.DefaultThrottleErrorCodes[apiError.ErrorCode()]; throttled {
+		return true
+	}
+	return apiError.ErrorFault() != smithy.FaultClient
+}
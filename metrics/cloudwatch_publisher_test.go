@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/smithy-go"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeCloudWatchClient struct {
+	mutex    sync.Mutex
+	failures []error
+	requests []*cloudwatch.PutMetricDataInput
+}
+
+func (client *fakeCloudWatchClient) PutMetricData(ctx context.Context, params *cloudwatch.PutMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricDataOutput, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	client.requests = append(client.requests, params)
+	if len(client.failures) > 0 {
+		err := client.failures[0]
+		client.failures = client.failures[1:]
+		return nil, err
+	}
+	return &cloudwatch.PutMetricDataOutput{}, nil
+}
+
+func (client *fakeCloudWatchClient) sentDatums() int {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	sent := 0
+	for _, request := range client.requests {
+		sent += len(request.MetricData)
+	}
+	return sent
+}
+
+// newTestPublisher has no background loop, so only the test flushes.
+func newTestPublisher(client CloudWatchClient) *CloudWatchPublisher {
+	return &CloudWatchPublisher{
+		client:        client,
+		namespace:     "Test",
+		flushInterval: time.Hour,
+		logger:        utils.LoggerOrNop(nil),
+		aggregates:    map[string]*aggregate{},
+		full:          make(chan struct{}, 1),
+	}
+}
+
+func countDatum(name string) types.MetricDatum {
+	return types.MetricDatum{MetricName: aws.String(name), Unit: types.StandardUnitCount, Value: aws.Float64(1)}
+}
+
+func TestPublisherRequeuesFailedBatches(t *testing.T) {
+	client := &fakeCloudWatchClient{failures: []error{errors.New("connection reset")}}
+	publisher := newTestPublisher(client)
+	publisher.Add(countDatum("Requests"))
+
+	if err := publisher.Flush(context.Background()); err == nil {
+		t.Fatal("expected the first flush to fail")
+	}
+	if err := publisher.Flush(context.Background()); err != nil {
+		t.Fatalf("second flush: %v", err)
+	}
+	if len(client.requests) != 2 || aws.ToString(client.requests[1].MetricData[0].MetricName) != "Requests" {
+		t.Fatalf("requests = %d, want the failed batch sent again", len(client.requests))
+	}
+}
+
+func TestPublisherDropsBatchesAfterMaxAttempts(t *testing.T) {
+	failure := errors.New("connection reset")
+	client := &fakeCloudWatchClient{failures: []error{failure, failure, failure, failure}}
+	publisher := newTestPublisher(client)
+	publisher.Add(countDatum("Requests"))
+	for i := 0; i < maxPublishAttempts+1; i++ {
+		publisher.Flush(context.Background())
+	}
+	if len(client.requests) != maxPublishAttempts {
+		t.Fatalf("sent %d times, want %d", len(client.requests), maxPublishAttempts)
+	}
+}
+
+func TestPublisherDropsRejectedBatches(t *testing.T) {
+	rejected := &smithy.GenericAPIError{Code: "InvalidParameterValue", Fault: smithy.FaultClient}
+	client := &fakeCloudWatchClient{failures: []error{rejected}}
+	publisher := newTestPublisher(client)
+	publisher.Add(countDatum("Requests"))
+	publisher.Flush(context.Background())
+	publisher.Flush(context.Background())
+	if len(client.requests) != 1 {
+		t.Fatalf("sent %d times, want the rejected batch dropped", len(client.requests))
+	}
+}
+
+func TestPublisherBoundsRequeuedDatums(t *testing.T) {
+	failure := errors.New("connection reset")
+	client := &fakeCloudWatchClient{}
+	publisher := newTestPublisher(client)
+	for flush := 0; flush < 2; flush++ {
+		for i := 0; i < maxRequeuedDatums; i++ {
+			publisher.Add(countDatum("Requests" + strconv.Itoa(flush) + "_" + strconv.Itoa(i)))
+		}
+		client.failures = make([]error, 2*maxRequeuedDatums/MaxDatumsPerRequest)
+		for i := range client.failures {
+			client.failures[i] = failure
+		}
+		publisher.Flush(context.Background())
+	}
+	client.failures = nil
+	before := client.sentDatums()
+	publisher.Flush(context.Background())
+	if sent := client.sentDatums() - before; sent != maxRequeuedDatums {
+		t.Fatalf("resent %d datums, want the limit of %d", sent, maxRequeuedDatums)
+	}
+}
+
+func TestPublisherBatchesByPayloadSize(t *testing.T) {
+	client := &fakeCloudWatchClient{}
+	publisher := newTestPublisher(client)
+	datums := 0
+	for i := 0; i < 200; i++ {
+		name := "Latency" + strconv.Itoa(i)
+		for value := 1; value <= 600; value += 4 {
+			publisher.Observe(types.MetricDatum{MetricName: aws.String(name), Unit: types.StandardUnitMilliseconds, Value: aws.Float64(float64(value))})
+		}
+	}
+	if err := publisher.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if len(client.requests) < 2 {
+		t.Fatalf("sent %d requests, want the datums split by size", len(client.requests))
+	}
+	for _, request := range client.requests {
+		size := 0
+		for _, datum := range request.MetricData {
+			size += encodedDatumSize(datum)
+			datums++
+		}
+		if size > maxRequestBytes || len(request.MetricData) > MaxDatumsPerRequest {
+			t.Fatalf("request of %d datums is %d bytes, limit is %d", len(request.MetricData), size, maxRequestBytes)
+		}
+	}
+	if datums < 200 {
+		t.Fatalf("sent %d datums, want every series", datums)
+	}
+}
@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// DistributionSummary describes every value recorded for one metric and operation since the process
+// started or ResetDistributions was called.
+type DistributionSummary struct {
+	Metric    string  `json:"metric"`
+	Operation string  `json:"operation,omitempty"`
+	Unit      Unit    `json:"unit"`
+	Count     float64 `json:"count"`
+	Min       float64 `json:"min"`
+	Max       float64 `json:"max"`
+	Mean      float64 `json:"mean"`
+	P50       float64 `json:"p50"`
+	P90       float64 `json:"p90"`
+	P99       float64 `json:"p99"`
+}
+
+// DistributionReader is implemented by the built-in managers; assert a MetricsManagerContract to it
+// to read percentiles, e.g. for a debugging endpoint.
+//
+// The local sketches are all-time: they cover every value since the process started or
+// ResetDistributions was last called, so a recent slowdown moves them slowly in a long-running
+// process. Call ResetDistributions on a timer to read percentiles over a window instead.
+type DistributionReader interface {
+	Percentile(metric string, operation string, q float64) (float64, bool)
+	Distributions() []DistributionSummary
+	DistributionsHandler() http.Handler
+	ResetDistributions()
+}
+
+type distributionKey struct {
+	metric    string
+	operation string
+}
+
+type distribution struct {
+	unit   Unit
+	sketch *Sketch
+}
+
+// distributions keeps a local sketch per metric and Operation dimension for every histogram and
+// timer, independent of what the backend publishes, so percentiles can be read in process.
+type distributions struct {
+	mutex    sync.Mutex
+	sketches map[distributionKey]*distribution
+}
+
+func (distributions *distributions) add(name string, unit Unit, value float64, dimensions []Dimension) {
+	key := distributionKey{metric: name}
+	for _, dimension := range dimensions {
+		if dimension.Name == DimensionOperation {
+			key.operation = dimension.Value
+		}
+	}
+	distributions.mutex.Lock()
+	defer distributions.mutex.Unlock()
+	if distributions.sketches == nil {
+		distributions.sketches = map[distributionKey]*distribution{}
+	}
+	existing, ok := distributions.sketches[key]
+	if !ok {
+		existing = &distribution{unit: unit, sketch: NewSketch(DefaultRelativeAccuracy)}
+		distributions.sketches[key] = existing
+	}
+	existing.sketch.Add(value)
+}
+
+// Percentile returns the q quantile (between 0 and 1) of metric for operation, e.g.
+// Percentile(MetricLatency, "GetUser", 0.99). The operation is empty for metrics without one.
+func (instruments *Instruments) Percentile(metric string, operation string, q float64) (float64, bool) {
+	instruments.distributions.mutex.Lock()
+	defer instruments.distributions.mutex.Unlock()
+	existing, ok := instruments.distributions.sketches[distributionKey{metric: metric, operation: operation}]
+	if !ok {
+		return 0, false
+	}
+	return existing.sketch.Quantile(q), true
+}
+
+// Distributions summarizes every histogram and timer, ordered by metric and operation.
+func (instruments *Instruments) Distributions() []DistributionSummary {
+	instruments.distributions.mutex.Lock()
+	defer instruments.distributions.mutex.Unlock()
+	summaries := make([]DistributionSummary, 0, len(instruments.distributions.sketches))
+	for key, existing := range instruments.distributions.sketches {
+		sketch := existing.sketch
+		summaries = append(summaries, DistributionSummary{
+			Metric:    key.metric,
+			Operation: key.operation,
+			Unit:      existing.unit,
+			Count:     sketch.Count(),
+			Min:       sketch.Min(),
+			Max:       sketch.Max(),
+			Mean:      sketch.Sum() / sketch.Count(),
+			P50:       sketch.Quantile(0.5),
+			P90:       sketch.Quantile(0.9),
+			P99:       sketch.Quantile(0.99),
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Metric != summaries[j].Metric {
+			return summaries[i].Metric < summaries[j].Metric
+		}
+		return summaries[i].Operation < summaries[j].Operation
+	})
+	return summaries
+}
+
+// ResetDistributions forgets every local sketch. Published metrics are not affected.
+func (instruments *Instruments) ResetDistributions() {
+	instruments.distributions.mutex.Lock()
+	defer instruments.distributions.mutex.Unlock()
+	instruments.distributions.sketches = nil
+}
+
+// DistributionsHandler serves Distributions as JSON for a debugging endpoint.
+func (instruments *Instruments) DistributionsHandler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(writer).Encode(instruments.Distributions())
+	})
+}
+
+var _ DistributionReader = (*MetricsManager)(nil)
+var _ DistributionReader = (*EmfMetricsManager)(nil)
@@ -0,0 +1,242 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// MaxMetricsPerEmfLine is the most metric definitions CloudWatch accepts in one EMF document.
+const MaxMetricsPerEmfLine = 100
+
+// emfMetadataKey is the root key CloudWatch reads the metric definitions from.
+const emfMetadataKey = "_aws"
+
+type EmfOptions struct {
+	Namespace string
+	// DefaultDimensions are added to every metric, as in MetricsOptions.
+	DefaultDimensions []Dimension
+	// Writer defaults to os.Stdout, which Lambda ships to CloudWatch Logs.
+	Writer io.Writer
+	// Properties are written on every line; CloudWatch keeps them searchable in Logs Insights but does not
+	// turn them into metrics or dimensions. A property named like a dimension of the line is left out.
+	Properties map[string]any
+	// HighResolution stores metrics at one second resolution instead of one minute.
+	HighResolution bool
+	// FlushInterval, when set, writes buffered metrics in the background. Lambda functions usually leave
+	// it at zero and call Flush before returning.
+	FlushInterval time.Duration
+	LogLevel      slog.Level
+	// Logger receives write failures and may be nil; SendLog does not go through it.
+	Logger *slog.Logger
+}
+
+type emfMetric struct {
+	unit   Unit
+	values []float64
+}
+
+// emfGroup holds the metrics that share one set of dimension values and so can share one line.
+type emfGroup struct {
+	dimensions []Dimension
+	metrics    map[string]*emfMetric
+	order      []string
+}
+
+// EmfMetricsManager writes metrics as CloudWatch embedded metric format log lines instead of calling
+// PutMetricData, so it costs nothing on the request path beyond a buffered write.
+type EmfMetricsManager struct {
+	*Instruments
+	options EmfOptions
+
+	mutex sync.Mutex
+	// sink serializes every write to the writer, both metric lines and SendLog and LogHandler events.
+	sink        *WriterLogSink
+	groups      map[string]*emfGroup
+	order       []string
+	stop        chan struct{}
+	done        chan struct{}
+	closeOnce   sync.Once
+	eventLogger *slog.Logger
+}
+
+func ProvideEmfMetricsManager(options EmfOptions) *EmfMetricsManager {
+	manager := &EmfMetricsManager{
+		options: options,
+		groups:  map[string]*emfGroup{},
+	}
+	manager.Instruments = ProvideInstruments(RecorderFunc(manager.record), options.DefaultDimensions, utils.LoggerOrNop(options.Logger))
+	writer := options.Writer
+	if writer == nil {
+		writer = os.Stdout
+	}
+	manager.sink = ProvideWriterLogSink(writer)
+	manager.eventLogger = slog.New(ProvideLogHandler(manager.sink, options.LogLevel))
+	if options.FlushInterval > 0 {
+		manager.stop = make(chan struct{})
+		manager.done = make(chan struct{})
+		go manager.run()
+	}
+	return manager
+}
+
+// SendLog writes an INFO event with tag as its tag to the same writer as the metrics.
+func (manager *EmfMetricsManager) SendLog(tag string, message string) {
+	manager.eventLogger.Info(message, TagAttribute, tag)
+}
+
+// LogHandler returns a slog.Handler that writes structured events next to the metrics.
+func (manager *EmfMetricsManager) LogHandler() slog.Handler {
+	return manager.eventLogger.Handler()
+}
+
+// Flush writes every buffered metric. ctx is accepted to match the other publishers; writes are not cancelled.
+func (manager *EmfMetricsManager) Flush(ctx context.Context) error {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	for _, key := range manager.order {
+		manager.writeGroup(manager.groups[key])
+	}
+	manager.groups = map[string]*emfGroup{}
+	manager.order = nil
+	return nil
+}
+
+func (manager *EmfMetricsManager) Close() error {
+	if manager.stop != nil {
+		manager.closeOnce.Do(func() {
+			close(manager.stop)
+			<-manager.done
+		})
+	}
+	return manager.Flush(context.TODO())
+}
+
+func (manager *EmfMetricsManager) run() {
+	defer close(manager.done)
+	ticker := time.NewTicker(manager.options.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-manager.stop:
+			return
+		case <-ticker.C:
+			manager.Flush(context.TODO())
+		}
+	}
+}
+
+// record drops metrics whose name would share a root key with a dimension, a property or the
+// metadata, since one of the values would overwrite the other on the line.
+func (manager *EmfMetricsManager) record(kind MetricKind, name string, unit Unit, value float64, dimensions []Dimension) {
+	if collision, ok := manager.collision(name, dimensions); ok {
+		utils.LoggerOrNop(manager.options.Logger).Warn("Dropping metric whose name collides on the EMF line", "metric", name, "collision", collision)
+		return
+	}
+	key := DimensionKey(dimensions)
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	group, ok := manager.groups[key]
+	if !ok {
+		group = &emfGroup{dimensions: dimensions, metrics: map[string]*emfMetric{}}
+		manager.groups[key] = group
+		manager.order = append(manager.order, key)
+	}
+	metric, ok := group.metrics[name]
+	if !ok {
+		if len(group.order) == MaxMetricsPerEmfLine {
+			manager.writeGroup(group)
+			group.metrics = map[string]*emfMetric{}
+			group.order = nil
+		}
+		metric = &emfMetric{unit: unit}
+		group.metrics[name] = metric
+		group.order = append(group.order, name)
+	}
+	metric.values = append(metric.values, value)
+	if len(metric.values) == MaxMetricsPerEmfLine {
+		manager.writeGroup(group)
+		group.metrics = map[string]*emfMetric{}
+		group.order = nil
+	}
+}
+
+// collision returns what a metric named name would overwrite on a line with dimensions.
+func (manager *EmfMetricsManager) collision(name string, dimensions []Dimension) (string, bool) {
+	if name == emfMetadataKey {
+		return "metadata", true
+	}
+	for _, dimension := range dimensions {
+		if dimension.Name == emfMetadataKey {
+			return "metadata", true
+		}
+		if dimension.Name == name {
+			return "dimension", true
+		}
+	}
+	if _, ok := manager.options.Properties[name]; ok {
+		return "property", true
+	}
+	return "", false
+}
+
+// writeGroup must be called with the mutex held.
+func (manager *EmfMetricsManager) writeGroup(group *emfGroup) {
+	if len(group.order) == 0 {
+		return
+	}
+	document := map[string]any{}
+	dimensionNames := make([]string, 0, len(group.dimensions))
+	for _, dimension := range group.dimensions {
+		dimensionNames = append(dimensionNames, dimension.Name)
+		document[dimension.Name] = dimension.Value
+	}
+	for name, value := range manager.options.Properties {
+		if _, ok := document[name]; ok || name == emfMetadataKey {
+			continue
+		}
+		document[name] = value
+	}
+	definitions := make([]map[string]any, 0, len(group.order))
+	for _, name := range group.order {
+		metric := group.metrics[name]
+		definition := map[string]any{"Name": name}
+		if len(metric.unit) > 0 {
+			definition["Unit"] = metric.unit
+		}
+		if manager.options.HighResolution {
+			definition["StorageResolution"] = 1
+		}
+		definitions = append(definitions, definition)
+		if len(metric.values) == 1 {
+			document[name] = metric.values[0]
+		} else {
+			document[name] = metric.values
+		}
+	}
+	document[emfMetadataKey] = map[string]any{
+		"Timestamp": time.Now().UnixMilli(),
+		"CloudWatchMetrics": []map[string]any{{
+			"Namespace":  manager.options.Namespace,
+			"Dimensions": [][]string{dimensionNames},
+			"Metrics":    definitions,
+		}},
+	}
+	line, err := json.Marshal(document)
+	if err != nil {
+		manager.logger.Error("Error in encoding metrics", "error", err)
+		return
+	}
+	manager.writeLine(line)
+}
+
+func (manager *EmfMetricsManager) writeLine(line []byte) {
+	if err := manager.sink.WriteEvent(time.Now(), line); err != nil {
+		manager.logger.Error("Error in writing metrics", "error", err)
+	}
+}
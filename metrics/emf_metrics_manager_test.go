@@ -0,0 +1,232 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/nicholaspark09/awsgorocket/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+type emfLine struct {
+	values     map[string]any
+	namespace  string
+	dimensions [][]string
+	metrics    []map[string]any
+}
+
+func emfLines(t *testing.T, output string) []emfLine {
+	t.Helper()
+	var lines []emfLine
+	for _, text := range strings.Split(strings.TrimSpace(output), "\n") {
+		if len(text) == 0 {
+			continue
+		}
+		var document struct {
+			Aws struct {
+				Timestamp         int64
+				CloudWatchMetrics []struct {
+					Namespace  string
+					Dimensions [][]string
+					Metrics    []map[string]any
+				}
+			} `json:"_aws"`
+		}
+		if err := json.Unmarshal([]byte(text), &document); err != nil {
+			t.Fatalf("line %s: %v", text, err)
+		}
+		if document.Aws.Timestamp == 0 || len(document.Aws.CloudWatchMetrics) != 1 {
+			t.Fatalf("line %s, want a timestamp and one metric directive", text)
+		}
+		values := map[string]any{}
+		if err := json.Unmarshal([]byte(text), &values); err != nil {
+			t.Fatal(err)
+		}
+		directive := document.Aws.CloudWatchMetrics[0]
+		lines = append(lines, emfLine{values: values, namespace: directive.Namespace, dimensions: directive.Dimensions, metrics: directive.Metrics})
+	}
+	return lines
+}
+
+func TestEmfLineShape(t *testing.T) {
+	var output bytes.Buffer
+	manager := ProvideEmfMetricsManager(EmfOptions{Namespace: "Orders", Writer: &output})
+	manager.Counter("Created", 1, Dim(DimensionOperation, "Create"))
+	manager.Counter("Created", 2, Dim(DimensionOperation, "Create"))
+	manager.Timer("Duration", 1500*time.Microsecond, Dim(DimensionOperation, "Create"))
+	if output.Len() != 0 {
+		t.Fatalf("output = %s, want metrics buffered until Flush", output.String())
+	}
+	if err := manager.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	lines := emfLines(t, output.String())
+	if len(lines) != 1 {
+		t.Fatalf("lines = %d, want one line for one set of dimensions", len(lines))
+	}
+	line := lines[0]
+	if line.namespace != "Orders" || fmt.Sprint(line.dimensions) != "[[Operation]]" {
+		t.Fatalf("namespace = %s, dimensions = %v", line.namespace, line.dimensions)
+	}
+	if line.values[DimensionOperation] != "Create" {
+		t.Fatalf("Operation = %v, want Create", line.values[DimensionOperation])
+	}
+	if fmt.Sprint(line.values["Created"]) != "[1 2]" || line.values["Duration"] != 1.5 {
+		t.Fatalf("values = %v, want Created [1 2] and Duration 1.5", line.values)
+	}
+	if len(line.metrics) != 2 || line.metrics[1]["Unit"] != string(UnitMilliseconds) {
+		t.Fatalf("metrics = %v, want Created and Duration in Milliseconds", line.metrics)
+	}
+	if _, ok := line.metrics[0]["StorageResolution"]; ok {
+		t.Fatalf("metrics = %v, want standard resolution by default", line.metrics)
+	}
+}
+
+func TestEmfSplitsAtMaxMetrics(t *testing.T) {
+	var output bytes.Buffer
+	manager := ProvideEmfMetricsManager(EmfOptions{Namespace: "Test", Writer: &output})
+	for i := 0; i <= MaxMetricsPerEmfLine; i++ {
+		manager.Counter(fmt.Sprintf("Metric%d", i), 1)
+	}
+	if lines := emfLines(t, output.String()); len(lines) != 1 || len(lines[0].metrics) != MaxMetricsPerEmfLine {
+		t.Fatalf("lines = %v, want one full line written before the extra metric", lines)
+	}
+	manager.Flush(context.Background())
+	lines := emfLines(t, output.String())
+	if len(lines) != 2 || len(lines[1].metrics) != 1 || lines[1].values[fmt.Sprintf("Metric%d", MaxMetricsPerEmfLine)] != 1.0 {
+		t.Fatalf("lines = %v, want the extra metric on its own line", lines)
+	}
+}
+
+func TestEmfSplitsAtMaxValues(t *testing.T) {
+	var output bytes.Buffer
+	manager := ProvideEmfMetricsManager(EmfOptions{Namespace: "Test", Writer: &output})
+	for i := 0; i < MaxMetricsPerEmfLine; i++ {
+		manager.Counter("Requests", 1)
+	}
+	lines := emfLines(t, output.String())
+	if len(lines) != 1 {
+		t.Fatalf("lines = %d, want a line written once one metric holds %d values", len(lines), MaxMetricsPerEmfLine)
+	}
+	if values, ok := lines[0].values["Requests"].([]any); !ok || len(values) != MaxMetricsPerEmfLine {
+		t.Fatalf("Requests = %v, want %d values", lines[0].values["Requests"], MaxMetricsPerEmfLine)
+	}
+	manager.Flush(context.Background())
+	if lines := emfLines(t, output.String()); len(lines) != 1 {
+		t.Fatalf("lines = %d, want nothing left to flush", len(lines))
+	}
+}
+
+func TestEmfHighResolution(t *testing.T) {
+	var output bytes.Buffer
+	manager := ProvideEmfMetricsManager(EmfOptions{Namespace: "Test", Writer: &output, HighResolution: true})
+	manager.Counter("Requests", 1)
+	manager.Flush(context.Background())
+	lines := emfLines(t, output.String())
+	if resolution := lines[0].metrics[0]["StorageResolution"]; resolution != 1.0 {
+		t.Fatalf("StorageResolution = %v, want 1", resolution)
+	}
+}
+
+func TestEmfProperties(t *testing.T) {
+	var output bytes.Buffer
+	manager := ProvideEmfMetricsManager(EmfOptions{
+		Namespace:  "Test",
+		Writer:     &output,
+		Properties: map[string]any{"Version": "1.2.3", "Stage": "property", "_aws": "property"},
+	})
+	manager.Counter("Requests", 1, Dim("Stage", "prod"))
+	manager.Flush(context.Background())
+	lines := emfLines(t, output.String())
+	if len(lines) != 1 {
+		t.Fatalf("lines = %d, want 1", len(lines))
+	}
+	values := lines[0].values
+	if values["Version"] != "1.2.3" {
+		t.Fatalf("Version = %v, want the property on the line", values["Version"])
+	}
+	if values["Stage"] != "prod" {
+		t.Fatalf("Stage = %v, want the dimension value kept over the property", values["Stage"])
+	}
+	if fmt.Sprint(lines[0].dimensions) != "[[Stage]]" {
+		t.Fatalf("dimensions = %v, want properties left out of the dimensions", lines[0].dimensions)
+	}
+}
+
+func TestEmfDropsCollidingMetrics(t *testing.T) {
+	var output bytes.Buffer
+	manager := ProvideEmfMetricsManager(EmfOptions{
+		Namespace:  "Test",
+		Writer:     &output,
+		Properties: map[string]any{"Version": "1.2.3"},
+	})
+	manager.Counter("_aws", 1)
+	manager.Counter("Version", 1)
+	manager.Counter("Stage", 1, Dim("Stage", "prod"))
+	manager.Counter("Requests", 1, Dim("_aws", "x"))
+	manager.Counter("Requests", 1)
+	manager.Flush(context.Background())
+	lines := emfLines(t, output.String())
+	if len(lines) != 1 || len(lines[0].metrics) != 1 || lines[0].metrics[0]["Name"] != "Requests" {
+		t.Fatalf("lines = %v, want only the Requests metric without dimensions", lines)
+	}
+	if lines[0].values["Version"] != "1.2.3" {
+		t.Fatalf("Version = %v, want the property kept", lines[0].values["Version"])
+	}
+}
+
+// lineWriter hands every write to the test, since the background flush writes from another goroutine.
+type lineWriter chan string
+
+func (writer lineWriter) Write(line []byte) (int, error) {
+	writer <- string(line)
+	return len(line), nil
+}
+
+func TestEmfFlushInterval(t *testing.T) {
+	writer := make(lineWriter, 1)
+	manager := ProvideEmfMetricsManager(EmfOptions{Namespace: "Test", Writer: writer, FlushInterval: 10 * time.Millisecond})
+	defer manager.Close()
+	manager.Counter("Requests", 1)
+	select {
+	case line := <-writer:
+		if lines := emfLines(t, line); lines[0].values["Requests"] != 1.0 {
+			t.Fatalf("line = %s, want Requests", line)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("metric was not flushed in the background")
+	}
+}
+
+func TestConfiguredEmfFlushesInBackground(t *testing.T) {
+	for _, test := range []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", defaultEmfFlushInterval},
+		{"250ms", 250 * time.Millisecond},
+		{"0s", defaultEmfFlushInterval},
+		{"soon", defaultEmfFlushInterval},
+	} {
+		values := map[string]string{"METRICS_BACKEND": "emf"}
+		if len(test.value) > 0 {
+			values["METRICS_FLUSH_INTERVAL"] = test.value
+		}
+		manager := ProvideConfiguredMetricsManager(config.ConfigProvider{
+			SdkConfig:        aws.Config{Region: "us-west-2"},
+			ConfigRepository: config.ProvideConfigRepository(config.ProvideMapSource("test", values)),
+		}, "orders")
+		emf, ok := manager.(*EmfMetricsManager)
+		if !ok {
+			t.Fatalf("manager = %T, want *EmfMetricsManager", manager)
+		}
+		if emf.options.FlushInterval != test.want || emf.stop == nil {
+			t.Fatalf("METRICS_FLUSH_INTERVAL=%q: FlushInterval = %v, want %v with the flush loop running", test.value, emf.options.FlushInterval, test.want)
+		}
+		emf.Close()
+	}
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/aws/smithy-go"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// MetricErrors counts failed calls with an Operation and an ErrorClass dimension.
+const (
+	MetricErrors        = "Errors"
+	DimensionErrorClass = "ErrorClass"
+)
+
+// Error classes, from the most to the least specific.
+const (
+	ErrorClassThrottle           = "Throttle"
+	ErrorClassNotFound           = "NotFound"
+	ErrorClassConditionalFailure = "ConditionalFailure"
+	// ErrorClassNetwork is a call that failed before a complete response arrived, so it has no status code.
+	ErrorClassNetwork = "Network"
+	ErrorClass4XX     = "4XX"
+	ErrorClass5XX     = "5XX"
+)
+
+// ErrNetwork marks an error as a failure to get a complete response; wrap an error with it to count it
+// as ErrorClassNetwork. *url.Error and *net.OpError count as network failures without it.
+var ErrNetwork = errors.New("network failure")
+
+var throttleErrorCodes = map[string]bool{
+	"ProvisionedThroughputExceededException": true,
+	"RequestLimitExceeded":                   true,
+	"ThrottlingException":                    true,
+	"Throttling":                             true,
+	"TooManyRequestsException":               true,
+	"SlowDown":                               true,
+}
+
+// ClassifyError returns the class of err and the HTTP status code behind it. AWS error codes are
+// checked first, then the status code of an AWS response error or a utils.GenericError. Without a
+// status code, AWS client faults count as 400, network failures as ErrorClassNetwork with a status
+// code of 0, and every other error as 500.
+func ClassifyError(err error) (string, int) {
+	statusCode := 0
+	var statusError interface{ HTTPStatusCode() int }
+	var genericError utils.GenericError
+	var genericErrorPointer *utils.GenericError
+	switch {
+	case errors.As(err, &statusError):
+		statusCode = statusError.HTTPStatusCode()
+	case errors.As(err, &genericError):
+		statusCode = genericError.StatusCode
+	case errors.As(err, &genericErrorPointer):
+		statusCode = genericErrorPointer.StatusCode
+	}
+	var apiError smithy.APIError
+	if errors.As(err, &apiError) {
+		if statusCode == 0 && apiError.ErrorFault() == smithy.FaultServer {
+			statusCode = http.StatusInternalServerError
+		} else if statusCode == 0 {
+			statusCode = http.StatusBadRequest
+		}
+		switch code := apiError.ErrorCode(); {
+		case throttleErrorCodes[code]:
+			return ErrorClassThrottle, statusCode
+		case code == "ResourceNotFoundException" || code == "NoSuchKey" || code == "NotFound":
+			return ErrorClassNotFound, statusCode
+		case code == "ConditionalCheckFailedException" || code == "TransactionConflictException":
+			return ErrorClassConditionalFailure, statusCode
+		}
+	}
+	if statusCode == 0 && isNetworkError(err) {
+		return ErrorClassNetwork, 0
+	}
+	if statusCode == 0 {
+		statusCode = http.StatusInternalServerError
+	}
+	return ClassifyStatusCode(statusCode), statusCode
+}
+
+func isNetworkError(err error) bool {
+	var urlError *url.Error
+	var opError *net.OpError
+	return errors.Is(err, ErrNetwork) || errors.As(err, &urlError) || errors.As(err, &opError)
+}
+
+// ClassifyStatusCode returns the error class of an HTTP status code of 400 or more.
+func ClassifyStatusCode(statusCode int) string {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return ErrorClassThrottle
+	case statusCode == http.StatusNotFound:
+		return ErrorClassNotFound
+	case statusCode == http.StatusPreconditionFailed || statusCode == http.StatusConflict:
+		return ErrorClassConditionalFailure
+	case statusCode >= 400 && statusCode < 500:
+		return ErrorClass4XX
+	}
+	return ErrorClass5XX
+}
+
+// CountError counts one Errors datum for operation with the class of err, and one 4XXError or
+// 5XXError datum through Send400Error or Send500Error. Network failures have no status code and
+// only count in Errors.
+func CountError(metricsManager MetricsManagerContract, operation string, err error) {
+	class, statusCode := ClassifyError(err)
+	countError(metricsManager, operation, class, statusCode, err.Error())
+}
+
+// CountStatusCode is CountError for calls that only report a status code of 400 or more.
+func CountStatusCode(metricsManager MetricsManagerContract, operation string, statusCode int, message string) {
+	countError(metricsManager, operation, ClassifyStatusCode(statusCode), statusCode, message)
+}
+
+func countError(metricsManager MetricsManagerContract, operation string, class string, statusCode int, message string) {
+	Counter(metricsManager, MetricErrors, 1, Dim(DimensionOperation, operation), Dim(DimensionErrorClass, class))
+	if class == ErrorClassNetwork {
+		return
+	}
+	if statusCode >= 400 && statusCode < 500 {
+		metricsManager.Send400Error(operation, statusCode, message)
+	} else {
+		metricsManager.Send500Error(operation, statusCode, message)
+	}
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"net"
+	"net/url"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	for _, test := range []struct {
+		err        error
+		class      string
+		statusCode int
+	}{
+		{utils.GenericError{StatusCode: 404}, ErrorClassNotFound, 404},
+		{utils.GenericError{StatusCode: 429}, ErrorClassThrottle, 429},
+		{utils.GenericError{StatusCode: 503}, ErrorClass5XX, 503},
+		{errors.New("failed"), ErrorClass5XX, 500},
+		{&url.Error{Op: "Get", URL: "http://localhost", Err: errors.New("connection refused")}, ErrorClassNetwork, 0},
+		{&net.OpError{Op: "read", Err: errors.New("connection reset")}, ErrorClassNetwork, 0},
+		{fmt.Errorf("%w: %w", ErrNetwork, errors.New("unexpected EOF")), ErrorClassNetwork, 0},
+	} {
+		if class, statusCode := ClassifyError(test.err); class != test.class || statusCode != test.statusCode {
+			t.Errorf("ClassifyError(%v) = %s, %d, want %s, %d", test.err, class, statusCode, test.class, test.statusCode)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package metrics
+
+import (
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"log/slog"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Unit values match the CloudWatch standard units so every backend can pass them through.
+type Unit string
+
+const (
+	UnitNone         Unit = "None"
+	UnitCount        Unit = "Count"
+	UnitPercent      Unit = "Percent"
+	UnitSeconds      Unit = "Seconds"
+	UnitMilliseconds Unit = "Milliseconds"
+	UnitMicroseconds Unit = "Microseconds"
+	UnitBytes        Unit = "Bytes"
+	UnitKilobytes    Unit = "Kilobytes"
+	UnitCountPerSec  Unit = "Count/Second"
+)
+
+type Dimension struct {
+	Name  string
+	Value string
+}
+
+// Dim is shorthand for Dimension{Name: name, Value: value}.
+func Dim(name string, value string) Dimension {
+	return Dimension{Name: name, Value: value}
+}
+
+// Stable metric names and dimensions used by the built-in helpers.
+const (
+	MetricLatency  = "Latency"
+	Metric4XXError = "4XXError"
+	Metric5XXError = "5XXError"
+
+	DimensionOperation  = "Operation"
+	DimensionStatusCode = "StatusCode"
+)
+
+const (
+	// MaxDimensions is the most dimensions kept on one datum; extra ones are dropped.
+	MaxDimensions = 10
+	// MaxSeriesPerMetric is the most distinct dimension value sets kept per metric name. Later sets
+	// are folded into one series whose values are all OtherDimensionValue.
+	MaxSeriesPerMetric      = 500
+	OtherDimensionValue     = "Other"
+	maxDimensionValueLength = 256
+)
+
+// MetricKind tells a Recorder how to aggregate a value.
+type MetricKind int
+
+const (
+	KindCounter MetricKind = iota
+	KindGauge
+	KindHistogram
+)
+
+// Recorder is what a backend implements; Instruments turns every public call into Record calls.
+// The dimensions passed to Record already include the defaults, are sorted by name and are bounded
+// by MaxDimensions and MaxSeriesPerMetric.
+type Recorder interface {
+	Record(kind MetricKind, name string, unit Unit, value float64, dimensions []Dimension)
+}
+
+// RecorderFunc adapts a function to a Recorder.
+type RecorderFunc func(kind MetricKind, name string, unit Unit, value float64, dimensions []Dimension)
+
+func (record RecorderFunc) Record(kind MetricKind, name string, unit Unit, value float64, dimensions []Dimension) {
+	record(kind, name, unit, value, dimensions)
+}
+
+// Instruments implements the MetricsManagerContract metric methods, InstrumentsContract and
+// DistributionReader on top of a backend's Recorder, so every backend shares the same names,
+// dimensions and cardinality limits. Backends embed the *Instruments from ProvideInstruments.
+type Instruments struct {
+	backend  Recorder
+	defaults []Dimension
+	guard    dimensionGuard
+	logger   *slog.Logger
+
+	distributions distributions
+}
+
+// ProvideInstruments adds defaultDimensions to every metric recorded through backend. logger
+// receives dropped dimensions and may be nil.
+func ProvideInstruments(backend Recorder, defaultDimensions []Dimension, logger *slog.Logger) *Instruments {
+	return &Instruments{
+		backend:  backend,
+		defaults: defaultDimensions,
+		logger:   logger,
+	}
+}
+
+func (instruments *Instruments) Counter(name string, value float64, dimensions ...Dimension) {
+	instruments.emit(KindCounter, name, UnitCount, value, dimensions)
+}
+
+func (instruments *Instruments) Gauge(name string, value float64, unit Unit, dimensions ...Dimension) {
+	instruments.emit(KindGauge, name, unit, value, dimensions)
+}
+
+func (instruments *Instruments) Histogram(name string, value float64, unit Unit, dimensions ...Dimension) {
+	instruments.emit(KindHistogram, name, unit, value, dimensions)
+}
+
+// Timer records duration in milliseconds as a histogram.
+func (instruments *Instruments) Timer(name string, duration time.Duration, dimensions ...Dimension) {
+	instruments.emit(KindHistogram, name, UnitMilliseconds, float64(duration)/float64(time.Millisecond), dimensions)
+}
+
+func (instruments *Instruments) SendMeasuredTime(callName string, timeDuration time.Duration) {
+	instruments.Timer(MetricLatency, timeDuration, Dim(DimensionOperation, callName))
+}
+
+// Send500Error counts one 5XX error. message is not recorded; it would make every error its own series.
+func (instruments *Instruments) Send500Error(callName string, statusCode int, message string) {
+	instruments.Counter(Metric5XXError, 1, Dim(DimensionOperation, callName), Dim(DimensionStatusCode, strconv.Itoa(statusCode)))
+}
+
+// Send400Error counts one 4XX error. message is not recorded; it would make every error its own series.
+func (instruments *Instruments) Send400Error(callName string, statusCode int, message string) {
+	instruments.Counter(Metric4XXError, 1, Dim(DimensionOperation, callName), Dim(DimensionStatusCode, strconv.Itoa(statusCode)))
+}
+
+func (instruments *Instruments) emit(kind MetricKind, name string, unit Unit, value float64, dimensions []Dimension) {
+	if len(instruments.defaults) > 0 {
+		dimensions = append(append(make([]Dimension, 0, len(instruments.defaults)+len(dimensions)), instruments.defaults...), dimensions...)
+	}
+	bounded, dropped := instruments.guard.bound(name, dimensions)
+	if dropped > 0 {
+		utils.LoggerOrNop(instruments.logger).Warn("Dropping dimensions", "metric", name, "dropped", dropped)
+	}
+	if kind == KindHistogram {
+		instruments.distributions.add(name, unit, value, bounded)
+	}
+	instruments.backend.Record(kind, name, unit, value, bounded)
+}
+
+type dimensionGuard struct {
+	mutex sync.Mutex
+	seen  map[string]map[string]struct{}
+}
+
+// bound sorts and de-duplicates dimensions by name (the last value wins), trims long values, drops
+// dimensions past MaxDimensions and caps the number of series per metric name. It also returns how
+// many dimensions were dropped.
+func (guard *dimensionGuard) bound(name string, dimensions []Dimension) ([]Dimension, int) {
+	if len(dimensions) == 0 {
+		return nil, 0
+	}
+	byName := make(map[string]string, len(dimensions))
+	for _, dimension := range dimensions {
+		if len(dimension.Name) == 0 || len(dimension.Value) == 0 {
+			continue
+		}
+		value := dimension.Value
+		if len(value) > maxDimensionValueLength {
+			value = value[:maxDimensionValueLength]
+		}
+		byName[dimension.Name] = value
+	}
+	bounded := make([]Dimension, 0, len(byName))
+	for dimensionName, value := range byName {
+		bounded = append(bounded, Dimension{Name: dimensionName, Value: value})
+	}
+	sort.Slice(bounded, func(i, j int) bool {
+		return bounded[i].Name < bounded[j].Name
+	})
+	dropped := 0
+	if len(bounded) > MaxDimensions {
+		dropped = len(bounded) - MaxDimensions
+		bounded = bounded[:MaxDimensions]
+	}
+	key := DimensionKey(bounded)
+	guard.mutex.Lock()
+	defer guard.mutex.Unlock()
+	if guard.seen == nil {
+		guard.seen = map[string]map[string]struct{}{}
+	}
+	series, ok := guard.seen[name]
+	if !ok {
+		series = map[string]struct{}{}
+		guard.seen[name] = series
+	}
+	if _, ok := series[key]; ok || len(series) < MaxSeriesPerMetric {
+		series[key] = struct{}{}
+		return bounded, dropped
+	}
+	for i := range bounded {
+		bounded[i].Value = OtherDimensionValue
+	}
+	return bounded, dropped
+}
+
+// DimensionKey identifies a sorted dimension set, e.g. to keep one series per set.
+func DimensionKey(dimensions []Dimension) string {
+	key := ""
+	for _, dimension := range dimensions {
+		key += dimension.Name + "=" + dimension.Value + "\x00"
+	}
+	return key
+}
+
+var _ InstrumentsContract = (*MetricsManager)(nil)
+var _ InstrumentsContract = (*EmfMetricsManager)(nil)
@@ -0,0 +1,302 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// CloudWatch Logs PutLogEvents limits. Every event counts its message size plus logEventOverhead bytes.
+const (
+	MaxLogBatchBytes  = 1048576
+	MaxLogBatchEvents = 10000
+	MaxLogEventBytes  = 256*1024 - logEventOverhead
+	logEventOverhead  = 26
+	maxLogBatchSpan   = 24 * time.Hour
+	defaultLogFlush   = 5 * time.Second
+	// maxRequeuedLogBytes bounds the events kept for the next flush while CloudWatch Logs is unavailable.
+	maxRequeuedLogBytes = 5 * MaxLogBatchBytes
+)
+
+// LogSink receives one encoded log event at a time.
+type LogSink interface {
+	WriteEvent(timestamp time.Time, line []byte) error
+}
+
+// WriterLogSink writes one event per line, e.g. to os.Stdout where Lambda ships it to CloudWatch Logs.
+type WriterLogSink struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+func ProvideWriterLogSink(writer io.Writer) *WriterLogSink {
+	return &WriterLogSink{writer: writer}
+}
+
+func (sink *WriterLogSink) WriteEvent(timestamp time.Time, line []byte) error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	_, err := sink.writer.Write(append(line, '\n'))
+	return err
+}
+
+type InputLogEvent struct {
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+// LogEventsClient sends events to one log stream. ProvideCloudWatchLogsClient is the AWS implementation.
+type LogEventsClient interface {
+	// PutLogEvents returns the next sequence token. A *SequenceTokenError asks the caller to retry with
+	// the expected token.
+	PutLogEvents(ctx context.Context, logGroup string, logStream string, events []InputLogEvent, sequenceToken *string) (*string, error)
+	CreateLogStream(ctx context.Context, logGroup string, logStream string) error
+}
+
+// SequenceTokenError is returned when the stream expected a different sequence token.
+type SequenceTokenError struct {
+	ExpectedSequenceToken *string
+	// AlreadyAccepted is true when the batch was a duplicate that CloudWatch Logs had already stored.
+	AlreadyAccepted bool
+}
+
+func (err *SequenceTokenError) Error() string {
+	if err.AlreadyAccepted {
+		return "log events were already accepted"
+	}
+	return "invalid log sequence token"
+}
+
+// ErrLogStreamNotFound is returned by a LogEventsClient when the group or stream does not exist.
+var ErrLogStreamNotFound = errors.New("log stream not found")
+
+type pendingLogBatch struct {
+	events   []InputLogEvent
+	bytes    int
+	attempts int
+}
+
+// CloudWatchLogsSink buffers events and ships them with PutLogEvents in batches that respect the
+// service limits, every flush interval or as soon as a batch is full.
+//
+// A batch that fails with a retryable error is sent again by the next flushes, up to maxPublishAttempts
+// times, while at most maxRequeuedLogBytes are waiting; the oldest batches are dropped first.
+type CloudWatchLogsSink struct {
+	client        LogEventsClient
+	logGroup      string
+	logStream     string
+	flushInterval time.Duration
+	logger        *slog.Logger
+
+	mutex         sync.Mutex
+	buffer        []InputLogEvent
+	bufferedBytes int
+	requeued      []pendingLogBatch
+
+	sendMutex     sync.Mutex
+	sequenceToken *string
+	streamCreated bool
+
+	full      chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// ProvideCloudWatchLogsSink starts the background flush loop. A flushInterval of zero uses five seconds.
+// logger receives background flush failures and may be nil; it must not write to this sink.
+func ProvideCloudWatchLogsSink(client LogEventsClient, logGroup string, logStream string, flushInterval time.Duration, logger *slog.Logger) *CloudWatchLogsSink {
+	if flushInterval <= 0 {
+		flushInterval = defaultLogFlush
+	}
+	sink := &CloudWatchLogsSink{
+		client:        client,
+		logGroup:      logGroup,
+		logStream:     logStream,
+		flushInterval: flushInterval,
+		logger:        utils.LoggerOrNop(logger),
+		full:          make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go sink.run()
+	return sink
+}
+
+// WriteEvent buffers the event, truncating it to MaxLogEventBytes.
+func (sink *CloudWatchLogsSink) WriteEvent(timestamp time.Time, line []byte) error {
+	message := truncateUtf8(line, MaxLogEventBytes)
+	sink.mutex.Lock()
+	sink.buffer = append(sink.buffer, InputLogEvent{Timestamp: timestamp.UnixMilli(), Message: message})
+	sink.bufferedBytes += len(message) + logEventOverhead
+	isFull := sink.bufferedBytes >= MaxLogBatchBytes || len(sink.buffer) >= MaxLogBatchEvents
+	sink.mutex.Unlock()
+	if isFull {
+		select {
+		case sink.full <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Flush ships the batches requeued by earlier flushes and every buffered event.
+func (sink *CloudWatchLogsSink) Flush(ctx context.Context) error {
+	sink.mutex.Lock()
+	events := sink.buffer
+	batches := sink.requeued
+	sink.buffer, sink.bufferedBytes, sink.requeued = nil, 0, nil
+	sink.mutex.Unlock()
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp < events[j].Timestamp
+	})
+	if len(events) > 0 {
+		for _, batch := range logBatches(events) {
+			batches = append(batches, pendingLogBatch{events: batch, bytes: logBatchBytes(batch)})
+		}
+	}
+	sink.sendMutex.Lock()
+	defer sink.sendMutex.Unlock()
+	var errs []error
+	var failed []pendingLogBatch
+	for _, batch := range batches {
+		err := sink.send(ctx, batch.events)
+		if err == nil {
+			continue
+		}
+		errs = append(errs, err)
+		batch.attempts++
+		if batch.attempts < maxPublishAttempts && isRetryable(err) {
+			failed = append(failed, batch)
+		} else {
+			errs = append(errs, fmt.Errorf("dropped %d log events after %d attempts", len(batch.events), batch.attempts))
+		}
+	}
+	if dropped := sink.requeue(failed); dropped > 0 {
+		errs = append(errs, fmt.Errorf("dropped %d requeued log events over the limit of %d bytes", dropped, maxRequeuedLogBytes))
+	}
+	return errors.Join(errs...)
+}
+
+// requeue keeps failed for the next flush and drops the oldest batches over maxRequeuedLogBytes. It
+// returns the number of events dropped.
+func (sink *CloudWatchLogsSink) requeue(failed []pendingLogBatch) int {
+	if len(failed) == 0 {
+		return 0
+	}
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	sink.requeued = append(failed, sink.requeued...)
+	total := 0
+	for _, batch := range sink.requeued {
+		total += batch.bytes
+	}
+	dropped := 0
+	for total > maxRequeuedLogBytes {
+		total -= sink.requeued[0].bytes
+		dropped += len(sink.requeued[0].events)
+		sink.requeued = sink.requeued[1:]
+	}
+	return dropped
+}
+
+func (sink *CloudWatchLogsSink) Close() error {
+	sink.closeOnce.Do(func() {
+		close(sink.stop)
+		<-sink.done
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), defaultPublishTimeout)
+	defer cancel()
+	return sink.Flush(ctx)
+}
+
+func (sink *CloudWatchLogsSink) run() {
+	defer close(sink.done)
+	ticker := time.NewTicker(sink.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sink.stop:
+			return
+		case <-ticker.C:
+		case <-sink.full:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), defaultPublishTimeout)
+		if err := sink.Flush(ctx); err != nil {
+			sink.logger.Error("Error in sending logs", "log_group", sink.logGroup, "log_stream", sink.logStream, "error", err)
+		}
+		cancel()
+	}
+}
+
+// send must be called with sendMutex held. It creates a missing stream and retries with the
+// expected sequence token, at most once each.
+func (sink *CloudWatchLogsSink) send(ctx context.Context, batch []InputLogEvent) error {
+	tokenRetried := false
+	for {
+		next, err := sink.client.PutLogEvents(ctx, sink.logGroup, sink.logStream, batch, sink.sequenceToken)
+		if err == nil {
+			sink.sequenceToken = next
+			return nil
+		}
+		var tokenError *SequenceTokenError
+		switch {
+		case errors.As(err, &tokenError) && !tokenRetried:
+			tokenRetried = true
+			sink.sequenceToken = tokenError.ExpectedSequenceToken
+			if tokenError.AlreadyAccepted {
+				return nil
+			}
+		case errors.Is(err, ErrLogStreamNotFound) && !sink.streamCreated:
+			if createError := sink.client.CreateLogStream(ctx, sink.logGroup, sink.logStream); createError != nil {
+				return createError
+			}
+			sink.streamCreated = true
+			sink.sequenceToken = nil
+		default:
+			return err
+		}
+	}
+}
+
+// logBatches splits time-ordered events by the byte, count and 24 hour span limits.
+func logBatches(events []InputLogEvent) [][]InputLogEvent {
+	var batches [][]InputLogEvent
+	start, size := 0, 0
+	for i, event := range events {
+		eventSize := len(event.Message) + logEventOverhead
+		if i > start && (size+eventSize > MaxLogBatchBytes || i-start >= MaxLogBatchEvents ||
+			event.Timestamp-events[start].Timestamp >= maxLogBatchSpan.Milliseconds()) {
+			batches = append(batches, events[start:i])
+			start, size = i, 0
+		}
+		size += eventSize
+	}
+	return append(batches, events[start:])
+}
+
+func logBatchBytes(events []InputLogEvent) int {
+	size := 0
+	for _, event := range events {
+		size += len(event.Message) + logEventOverhead
+	}
+	return size
+}
+
+func truncateUtf8(line []byte, limit int) string {
+	if len(line) <= limit {
+		return string(line)
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return string(line[:cut])
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"context"
+	"github.com/nicholaspark09/awsgorocket/tracing"
+	"time"
+)
+
+// Metrics recorded by Measure, Measure2 and MeasureVoid. Success and Failure count calls by
+// operation. Every call records Latency through SendMeasuredTime, so it shares one series per
+// operation with calls timed by hand, and also SuccessLatency or FailureLatency, so each outcome's
+// latency can be queried on its own.
+const (
+	MetricSuccess        = "Success"
+	MetricFailure        = "Failure"
+	MetricSuccessLatency = "SuccessLatency"
+	MetricFailureLatency = "FailureLatency"
+)
+
+// Measure runs f in a span named callName and records its outcome: a Success or Failure count, its
+// Latency and SuccessLatency or FailureLatency with an Operation dimension and, for failures, the
+// error counts of CountError, so a utils.GenericError with a 404 counts as NotFound and 4XXError.
+func Measure[T any](ctx context.Context, callName string, metricsManager MetricsManagerContract, f func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := MeasureVoid(ctx, callName, metricsManager, func(ctx context.Context) error {
+		var err error
+		result, err = f(ctx)
+		return err
+	})
+	return result, err
+}
+
+// Measure2 is Measure for functions that return two values and an error.
+func Measure2[A any, B any](ctx context.Context, callName string, metricsManager MetricsManagerContract, f func(ctx context.Context) (A, B, error)) (A, B, error) {
+	var first A
+	var second B
+	err := MeasureVoid(ctx, callName, metricsManager, func(ctx context.Context) error {
+		var err error
+		first, second, err = f(ctx)
+		return err
+	})
+	return first, second, err
+}
+
+// MeasureVoid is Measure for functions that only return an error.
+func MeasureVoid(ctx context.Context, callName string, metricsManager MetricsManagerContract, f func(ctx context.Context) error) error {
+	ctx, span := tracing.Tracer(nil).Start(tracing.Context(ctx), callName)
+	defer span.End()
+	start := time.Now()
+	err := f(ctx)
+	RecordOutcome(metricsManager, callName, time.Since(start), err)
+	tracing.RecordError(span, err)
+	return err
+}
+
+// RecordOutcome records what Measure records for a call that took duration and returned err, for
+// callers that time the call themselves.
+func RecordOutcome(metricsManager MetricsManagerContract, operation string, duration time.Duration, err error) {
+	if metricsManager == nil {
+		return
+	}
+	metricsManager.SendMeasuredTime(operation, duration)
+	if err == nil {
+		Counter(metricsManager, MetricSuccess, 1, Dim(DimensionOperation, operation))
+		Timer(metricsManager, MetricSuccessLatency, duration, Dim(DimensionOperation, operation))
+		return
+	}
+	Counter(metricsManager, MetricFailure, 1, Dim(DimensionOperation, operation))
+	Timer(metricsManager, MetricFailureLatency, duration, Dim(DimensionOperation, operation))
+	CountError(metricsManager, operation, err)
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func latencyCounts(manager DistributionReader) map[string]float64 {
+	counts := map[string]float64{}
+	for _, summary := range manager.Distributions() {
+		counts[summary.Metric+" "+summary.Operation] = summary.Count
+	}
+	return counts
+}
+
+func TestRecordOutcomeSplitsLatencyByOutcome(t *testing.T) {
+	var output bytes.Buffer
+	manager := ProvideEmfMetricsManager(EmfOptions{Namespace: "Test", Writer: &output})
+	manager.SendMeasuredTime("GetOrder", 10*time.Millisecond)
+	RecordOutcome(manager, "GetOrder", 20*time.Millisecond, nil)
+	RecordOutcome(manager, "GetOrder", 30*time.Millisecond, errors.New("failed"))
+	counts := latencyCounts(manager)
+	if counts[MetricLatency+" GetOrder"] != 3 {
+		t.Fatalf("Latency counts = %v, want every call in one GetOrder series", counts)
+	}
+	if counts[MetricSuccessLatency+" GetOrder"] != 1 {
+		t.Fatalf("SuccessLatency counts = %v, want the successful call", counts)
+	}
+	if counts[MetricFailureLatency+" GetOrder"] != 1 {
+		t.Fatalf("FailureLatency counts = %v, want the failed call", counts)
+	}
+	if len(counts) != 3 {
+		t.Fatalf("distributions = %v, want only Latency, SuccessLatency and FailureLatency", counts)
+	}
+	if err := manager.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(output.String(), "Outcome") {
+		t.Fatalf("output = %s, want latencies published with the Operation dimension only", output.String())
+	}
+}
+
+func TestRecordOutcomeSkipsNilManager(t *testing.T) {
+	RecordOutcome(nil, "GetOrder", time.Millisecond, errors.New("failed"))
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"context"
+	"github.com/nicholaspark09/awsgorocket/config"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	BackendCloudWatch = "cloudwatch"
+	BackendEmf        = "emf"
+	BackendOtel       = "otel"
+	BackendPrometheus = "prometheus"
+)
+
+// defaultEmfFlushInterval bounds how long the configured EMF backend buffers a metric, since callers
+// that only hold a MetricsManagerContract never call Flush.
+const defaultEmfFlushInterval = 10 * time.Second
+
+// Flusher is implemented by the managers that buffer metrics.
+type Flusher interface {
+	Flush(ctx context.Context) error
+	Close() error
+}
+
+// BackendFactory builds the manager of a backend registered with RegisterBackend from the options
+// ProvideConfiguredMetricsManager read.
+type BackendFactory func(provider config.ConfigProvider, options MetricsOptions) MetricsManagerContract
+
+var (
+	backendsMutex sync.RWMutex
+	backends      = map[string]BackendFactory{}
+)
+
+// RegisterBackend makes a backend available to ProvideConfiguredMetricsManager under name. The
+// backends with their own modules call it from init, so importing them is enough, e.g.
+// import _ "github.com/nicholaspark09/awsgorocket/metrics/prometheusmetrics".
+func RegisterBackend(name string, factory BackendFactory) {
+	backendsMutex.Lock()
+	defer backendsMutex.Unlock()
+	backends[strings.ToLower(name)] = factory
+}
+
+// ProvideConfiguredMetricsManager picks the backend named by METRICS_BACKEND: "cloudwatch" (the default,
+// also used for unknown names), "emf", or a backend registered with RegisterBackend such as "otel" or
+// "prometheus", with the namespace and default dimensions from OptionsFromRepository. The EMF backend
+// also reads METRICS_HIGH_RESOLUTION and METRICS_FLUSH_INTERVAL (10s by default).
+func ProvideConfiguredMetricsManager(provider config.ConfigProvider, serviceName string) MetricsManagerContract {
+	repository := provider.ConfigRepository
+	if repository == nil {
+		repository = &config.ConfigRepository{}
+	}
+	options := OptionsFromRepository(provider, serviceName)
+	backend := strings.ToLower(repository.GetString("METRICS_BACKEND", BackendCloudWatch))
+	switch backend {
+	case BackendEmf:
+		highResolution, err := config.AsTypedConfig(repository).GetBool("METRICS_HIGH_RESOLUTION", false)
+		if err != nil {
+			utils.LoggerOrNop(options.Logger).Warn("Error in reading METRICS_HIGH_RESOLUTION", "error", err)
+		}
+		flushInterval, err := config.AsTypedConfig(repository).GetDuration("METRICS_FLUSH_INTERVAL", defaultEmfFlushInterval)
+		if err != nil || flushInterval <= 0 {
+			utils.LoggerOrNop(options.Logger).Warn("Invalid METRICS_FLUSH_INTERVAL", "error", err, "using", defaultEmfFlushInterval)
+			flushInterval = defaultEmfFlushInterval
+		}
+		return ProvideEmfMetricsManager(EmfOptions{
+			Namespace:         options.Namespace,
+			DefaultDimensions: options.DefaultDimensions,
+			HighResolution:    highResolution,
+			FlushInterval:     flushInterval,
+			LogLevel:          options.LogLevel,
+			Logger:            options.Logger,
+		})
+	case BackendCloudWatch:
+		return ProvideMetricsManagerWithOptions(provider, options)
+	}
+	backendsMutex.RLock()
+	factory, ok := backends[backend]
+	backendsMutex.RUnlock()
+	if ok {
+		return factory(provider, options)
+	}
+	utils.LoggerOrNop(options.Logger).Warn("Unknown metrics backend, import its package to register it", "backend", backend, "using", BackendCloudWatch)
+	return ProvideMetricsManagerWithOptions(provider, options)
+}
+
+// Flush flushes metricsManager when it buffers metrics and does nothing otherwise.
+func Flush(ctx context.Context, metricsManager MetricsManagerContract) error {
+	if flusher, ok := metricsManager.(Flusher); ok {
+		return flusher.Flush(ctx)
+	}
+	return nil
+}
@@ -2,92 +2,85 @@ package metrics
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
 	"github.com/nicholaspark09/awsgorocket/config"
-	"log"
+	"log/slog"
 	"time"
 )
 
 type MetricsManager struct {
-	client      *cloudwatch.Client
-	serviceName string
+	*Instruments
+	publisher   *CloudWatchPublisher
+	logSink     LogSink
+	eventLogger *slog.Logger
 }
 
-const nameSpace = "PipelineFacadeService"
-
+// ProvideMetricsManager publishes under the namespace and default dimensions from OptionsFromRepository.
 func ProvideMetricsManager(provider config.ConfigProvider, serviceName string) MetricsManagerContract {
-	cloudWatchClient := cloudwatch.NewFromConfig(provider.SdkConfig)
-	return &MetricsManager{
-		client:      cloudWatchClient,
-		serviceName: serviceName,
-	}
+	return ProvideMetricsManagerWithOptions(provider, OptionsFromRepository(provider, serviceName))
 }
 
-func (metricsManager *MetricsManager) SendMeasuredTime(callName string, timeDuration time.Duration) {
-	title := "ExecutionTime:" + callName
-	elapsed := timeDuration.Milliseconds()
-	metricDatum := &types.MetricDatum{
-		MetricName: aws.String(title),
-		Timestamp:  aws.Time(time.Now().UTC()),
-		Dimensions: []types.Dimension{
-			{Name: aws.String("ApiLatency"),
-				Value: aws.String(fmt.Sprintf("%d", elapsed))},
-		},
-		Value: aws.Float64(float64(elapsed)),
-	}
-	_, err := metricsManager.client.PutMetricData(context.TODO(), &cloudwatch.PutMetricDataInput{
-		MetricData: []types.MetricDatum{*metricDatum},
-		Namespace:  aws.String(metricsManager.serviceName),
+func ProvideMetricsManagerWithOptions(provider config.ConfigProvider, options MetricsOptions) *MetricsManager {
+	cloudWatchClient := cloudwatch.NewFromConfig(provider.SdkConfig, func(cloudWatchOptions *cloudwatch.Options) {
+		cloudWatchOptions.BaseEndpoint = provider.Environment.BaseEndpoint(config.ServiceCloudWatch)
 	})
-	log.Printf("%s, Time: %v", title, elapsed)
-	if err != nil {
-		log.Printf("Error in sending metrics: %s", err.Error())
-	} else {
-		fmt.Printf("Method %s took %v to complete", callName, elapsed)
+	metricsManager := &MetricsManager{
+		publisher: ProvideCloudWatchPublisher(cloudWatchClient, options.Namespace, defaultFlushInterval, options.Logger),
 	}
+	metricsManager.Instruments = ProvideInstruments(RecorderFunc(metricsManager.record), options.DefaultDimensions, options.Logger)
+	metricsManager.logSink = ConfiguredLogSink(provider, options)
+	metricsManager.eventLogger = slog.New(ProvideLogHandler(metricsManager.logSink, options.LogLevel))
+	return metricsManager
 }
 
-func (metricsManager *MetricsManager) Send500Error(callName string, statusCode int, message string) {
-	metricDatum := &types.MetricDatum{
-		MetricName: aws.String("5XXError:" + callName),
-		Timestamp:  aws.Time(time.Now().UTC()),
-		Dimensions: []types.Dimension{
-			{Name: aws.String("ApiError"),
-				Value: aws.String(message)},
-		},
-		Value: aws.Float64(float64(statusCode)),
+// Flush sends every buffered datum and log event now, e.g. at the end of a Lambda invocation.
+func (metricsManager *MetricsManager) Flush(ctx context.Context) error {
+	err := metricsManager.publisher.Flush(ctx)
+	if flusher, ok := metricsManager.logSink.(Flusher); ok {
+		err = errors.Join(err, flusher.Flush(ctx))
 	}
-	_, err := metricsManager.client.PutMetricData(context.TODO(), &cloudwatch.PutMetricDataInput{
-		MetricData: []types.MetricDatum{*metricDatum},
-		Namespace:  aws.String(metricsManager.serviceName),
-	})
-	if err != nil {
-		log.Printf("Error in sending metrics: %s", err.Error())
+	return err
+}
+
+// Close stops the background publishers after a final flush.
+func (metricsManager *MetricsManager) Close() error {
+	err := metricsManager.publisher.Close()
+	if flusher, ok := metricsManager.logSink.(Flusher); ok {
+		err = errors.Join(err, flusher.Close())
 	}
+	return err
+}
+
+// LogHandler returns a slog.Handler that writes to the same destination as SendLog, e.g.
+// slog.New(metricsManager.LogHandler()).
+func (metricsManager *MetricsManager) LogHandler() slog.Handler {
+	return metricsManager.eventLogger.Handler()
 }
 
-func (metricsManager *MetricsManager) Send400Error(callName string, statusCode int, message string) {
-	metricDatum := &types.MetricDatum{
-		MetricName: aws.String("4XXError:" + callName),
+func (metricsManager *MetricsManager) record(kind MetricKind, name string, unit Unit, value float64, dimensions []Dimension) {
+	metricDatum := types.MetricDatum{
+		MetricName: aws.String(name),
 		Timestamp:  aws.Time(time.Now().UTC()),
-		Dimensions: []types.Dimension{
-			{Name: aws.String("ApiError"),
-				Value: aws.String(message)},
-		},
-		Value: aws.Float64(float64(statusCode)),
+		Unit:       types.StandardUnit(unit),
+		Value:      aws.Float64(value),
 	}
-	_, err := metricsManager.client.PutMetricData(context.TODO(), &cloudwatch.PutMetricDataInput{
-		MetricData: []types.MetricDatum{*metricDatum},
-		Namespace:  aws.String(metricsManager.serviceName),
-	})
-	if err != nil {
-		log.Printf("Error in sending metrics: %s", err.Error())
+	for _, dimension := range dimensions {
+		metricDatum.Dimensions = append(metricDatum.Dimensions, types.Dimension{
+			Name:  aws.String(dimension.Name),
+			Value: aws.String(dimension.Value),
+		})
+	}
+	if kind == KindHistogram {
+		metricsManager.publisher.Observe(metricDatum)
+		return
 	}
+	metricsManager.publisher.Add(metricDatum)
 }
 
+// SendLog writes an INFO event with tag as its tag.
 func (metricsManager *MetricsManager) SendLog(tag string, message string) {
-	// TODO
+	metricsManager.eventLogger.Info(message, TagAttribute, tag)
 }
@@ -8,3 +8,44 @@ type MetricsManagerContract interface {
 	Send500Error(callName string, statusCode int, message string)
 	Send400Error(callName string, statusCode int, message string)
 }
+
+// InstrumentsContract records named metrics with a unit and dimensions. Call it through the Counter,
+// Gauge, Histogram and Timer functions, which skip managers that don't implement it.
+type InstrumentsContract interface {
+	// Counter adds value to a count, e.g. Counter("Retries", 1, Dim("Operation", "FetchUser")).
+	Counter(name string, value float64, dimensions ...Dimension)
+	// Gauge records the current level of something, such as a queue depth.
+	Gauge(name string, value float64, unit Unit, dimensions ...Dimension)
+	// Histogram records one observation of a distribution, such as a payload size.
+	Histogram(name string, value float64, unit Unit, dimensions ...Dimension)
+	// Timer records a duration as a histogram in milliseconds.
+	Timer(name string, duration time.Duration, dimensions ...Dimension)
+}
+
+// Counter calls InstrumentsContract.Counter when metricsManager implements it.
+func Counter(metricsManager MetricsManagerContract, name string, value float64, dimensions ...Dimension) {
+	if instruments, ok := metricsManager.(InstrumentsContract); ok {
+		instruments.Counter(name, value, dimensions...)
+	}
+}
+
+// Gauge calls InstrumentsContract.Gauge when metricsManager implements it.
+func Gauge(metricsManager MetricsManagerContract, name string, value float64, unit Unit, dimensions ...Dimension) {
+	if instruments, ok := metricsManager.(InstrumentsContract); ok {
+		instruments.Gauge(name, value, unit, dimensions...)
+	}
+}
+
+// Histogram calls InstrumentsContract.Histogram when metricsManager implements it.
+func Histogram(metricsManager MetricsManagerContract, name string, value float64, unit Unit, dimensions ...Dimension) {
+	if instruments, ok := metricsManager.(InstrumentsContract); ok {
+		instruments.Histogram(name, value, unit, dimensions...)
+	}
+}
+
+// Timer calls InstrumentsContract.Timer when metricsManager implements it.
+func Timer(metricsManager MetricsManagerContract, name string, duration time.Duration, dimensions ...Dimension) {
+	if instruments, ok := metricsManager.(InstrumentsContract); ok {
+		instruments.Timer(name, duration, dimensions...)
+	}
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+// basicManager implements only MetricsManagerContract, like managers written before InstrumentsContract.
+type basicManager struct{}
+
+func (manager *basicManager) SendMeasuredTime(callName string, time time.Duration) {}
+
+func (manager *basicManager) SendLog(tag string, message string) {}
+
+func (manager *basicManager) Send500Error(callName string, statusCode int, message string) {}
+
+func (manager *basicManager) Send400Error(callName string, statusCode int, message string) {}
+
+type instrumentedManager struct {
+	basicManager
+	counters map[string]float64
+}
+
+func (manager *instrumentedManager) Counter(name string, value float64, dimensions ...Dimension) {
+	manager.counters[name] += value
+}
+
+func (manager *instrumentedManager) Gauge(name string, value float64, unit Unit, dimensions ...Dimension) {
+}
+
+func (manager *instrumentedManager) Histogram(name string, value float64, unit Unit, dimensions ...Dimension) {
+}
+
+func (manager *instrumentedManager) Timer(name string, duration time.Duration, dimensions ...Dimension) {
+}
+
+func TestInstrumentFunctionsSkipManagersWithoutInstruments(t *testing.T) {
+	managers := []MetricsManagerContract{nil, &basicManager{}}
+	for _, manager := range managers {
+		Counter(manager, "Retries", 1)
+		Gauge(manager, "QueueDepth", 3, UnitCount)
+		Histogram(manager, "PayloadSize", 512, UnitBytes)
+		Timer(manager, MetricLatency, time.Second)
+	}
+}
+
+func TestInstrumentFunctionsUseInstrumentsContract(t *testing.T) {
+	manager := &instrumentedManager{counters: map[string]float64{}}
+	Counter(manager, "Retries", 2, Dim(DimensionOperation, "FetchUser"))
+	if manager.counters["Retries"] != 2 {
+		t.Fatalf("Retries = %v, want 2", manager.counters["Retries"])
+	}
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"github.com/nicholaspark09/awsgorocket/config"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Default dimension names OptionsFromRepository can attach to every datum.
+const (
+	DimensionService = "Service"
+	DimensionStage   = "Stage"
+	DimensionRegion  = "Region"
+)
+
+type MetricsOptions struct {
+	Namespace string
+	// DefaultDimensions are added to every datum. A dimension of the same name passed to a call
+	// overrides the default for that call; defaults with empty values are skipped.
+	DefaultDimensions []Dimension
+
+	// LogSink receives SendLog and LogHandler events. When nil, events go to LogGroup and LogStream in
+	// CloudWatch Logs, or to stdout in Lambda or when LogGroup is empty.
+	LogSink   LogSink
+	LogGroup  string
+	LogStream string
+	LogLevel  slog.Level
+
+	// Logger receives the manager's own diagnostics, such as failed flushes, and may be nil.
+	// It is separate from SendLog and LogHandler.
+	Logger *slog.Logger
+}
+
+// OptionsFromRepository reads METRICS_NAMESPACE (serviceName by default) and METRICS_DEFAULT_DIMENSIONS,
+// a comma-separated list of the default dimensions to attach, e.g. "Service,Stage". There are none by
+// default, since each one multiplies the series of every metric. Service is read from SERVICE_NAME,
+// Stage from STAGE or ENVIRONMENT_PROFILE and Region from REGION or the region the SDK resolved.
+// Logging reads LOG_GROUP, LOG_STREAM (the host name by default) and LOG_LEVEL, which falls back to
+// INFO when it is not a slog level. Logger is the provider's Logger, which also receives warnings about
+// unusable values; without one nothing is logged.
+func OptionsFromRepository(provider config.ConfigProvider, serviceName string) MetricsOptions {
+	repository := provider.ConfigRepository
+	if repository == nil {
+		repository = &config.ConfigRepository{}
+	}
+	service := repository.GetString("SERVICE_NAME", serviceName)
+	hostName, _ := os.Hostname()
+	logger := utils.LoggerOrNop(provider.Logger)
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(repository.GetString("LOG_LEVEL", "INFO"))); err != nil {
+		logger.Warn("Error in reading LOG_LEVEL, using INFO", "error", err)
+		level = slog.LevelInfo
+	}
+	available := map[string]string{
+		DimensionService: service,
+		DimensionStage:   repository.GetString("STAGE", repository.GetString("ENVIRONMENT_PROFILE", "")),
+		DimensionRegion:  repository.GetString("REGION", provider.SdkConfig.Region),
+	}
+	var defaultDimensions []Dimension
+	for _, name := range strings.Split(repository.GetString("METRICS_DEFAULT_DIMENSIONS", ""), ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+		value, ok := available[name]
+		if !ok {
+			logger.Warn("Unknown default dimension in METRICS_DEFAULT_DIMENSIONS", "dimension", name)
+			continue
+		}
+		defaultDimensions = append(defaultDimensions, Dim(name, value))
+	}
+	return MetricsOptions{
+		Namespace:         repository.GetString("METRICS_NAMESPACE", serviceName),
+		DefaultDimensions: defaultDimensions,
+		LogGroup:          repository.GetString("LOG_GROUP", ""),
+		LogStream:         repository.GetString("LOG_STREAM", hostName),
+		LogLevel:          level,
+		Logger:            provider.Logger,
+	}
+}
+
+// ConfiguredLogSink picks where log events go for options, as described on MetricsOptions.LogSink.
+func ConfiguredLogSink(provider config.ConfigProvider, options MetricsOptions) LogSink {
+	if options.LogSink != nil {
+		return options.LogSink
+	}
+	if len(options.LogGroup) == 0 || len(os.Getenv("AWS_LAMBDA_FUNCTION_NAME")) > 0 {
+		return ProvideWriterLogSink(os.Stdout)
+	}
+	return ProvideCloudWatchLogsSink(ProvideCloudWatchLogsClient(provider), options.LogGroup, options.LogStream, 0, options.Logger)
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"bytes"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/nicholaspark09/awsgorocket/config"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func optionsFrom(values map[string]string) MetricsOptions {
+	return optionsWithLogger(values, nil)
+}
+
+func optionsWithLogger(values map[string]string, logger *slog.Logger) MetricsOptions {
+	return OptionsFromRepository(config.ConfigProvider{
+		SdkConfig:        aws.Config{Region: "us-west-2"},
+		ConfigRepository: config.ProvideConfigRepository(config.ProvideMapSource("test", values)),
+		Logger:           logger,
+	}, "orders")
+}
+
+func TestDefaultDimensionsAreOptIn(t *testing.T) {
+	options := optionsFrom(map[string]string{"STAGE": "prod", "VERSION": "1.2.3"})
+	if len(options.DefaultDimensions) != 0 {
+		t.Fatalf("DefaultDimensions = %v, want none", options.DefaultDimensions)
+	}
+}
+
+func TestDefaultDimensionsFromConfig(t *testing.T) {
+	options := optionsFrom(map[string]string{
+		"METRICS_DEFAULT_DIMENSIONS": "Service, Region,Version",
+		"VERSION":                    "1.2.3",
+	})
+	want := []Dimension{Dim(DimensionService, "orders"), Dim(DimensionRegion, "us-west-2")}
+	if !reflect.DeepEqual(options.DefaultDimensions, want) {
+		t.Fatalf("DefaultDimensions = %v, want %v", options.DefaultDimensions, want)
+	}
+}
+
+func TestOptionsFromRepositoryLogsUnusableValues(t *testing.T) {
+	var output bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&output, nil))
+	options := optionsWithLogger(map[string]string{"LOG_LEVEL": "LOUD", "METRICS_DEFAULT_DIMENSIONS": "Version"}, logger)
+	if options.Logger != logger {
+		t.Fatal("expected OptionsFromRepository to pass on the provider's Logger")
+	}
+	if options.LogLevel != slog.LevelInfo {
+		t.Fatalf("LogLevel = %v, want INFO", options.LogLevel)
+	}
+	for _, want := range []string{"LOG_LEVEL", "METRICS_DEFAULT_DIMENSIONS"} {
+		if !strings.Contains(output.String(), want) {
+			t.Fatalf("log output %q does not mention %s", output.String(), want)
+		}
+	}
+}
+
+func TestOptionsFromRepositoryLogsNothingByDefault(t *testing.T) {
+	var output bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&output, nil)))
+	defer slog.SetDefault(previous)
+
+	options := optionsFrom(map[string]string{"LOG_LEVEL": "LOUD"})
+	if options.Logger != nil {
+		t.Fatalf("Logger = %v, want nil without a provider Logger", options.Logger)
+	}
+	if output.Len() > 0 {
+		t.Fatalf("log output %q, want nothing on the default logger", output.String())
+	}
+}
@@ -1,6 +1,10 @@
 package metrics
 
-import "time"
+import (
+	"context"
+	"github.com/nicholaspark09/awsgorocket/tracing"
+	"time"
+)
 
 func MeasureTime[T any](callName string, metricsManager MetricsManagerContract, f func() *T) *T {
 	start := time.Now()
@@ -15,3 +19,28 @@ func MeasureTimeWithError[T any](callName string, metricsManager MetricsManagerC
 	metricsManager.SendMeasuredTime(callName, time.Since(start))
 	return result, err
 }
+
+// MeasureTimeInSpan is MeasureTime that also runs f in a span named callName, a child of the span in
+// ctx, using the global tracer provider.
+func MeasureTimeInSpan[T any](ctx context.Context, callName string, metricsManager MetricsManagerContract, f func(ctx context.Context) *T) *T {
+	ctx, span := tracing.Tracer(nil).Start(tracing.Context(ctx), callName)
+	defer span.End()
+	start := time.Now()
+	result := f(ctx)
+	metricsManager.SendMeasuredTime(callName, time.Since(start))
+	return result
+}
+
+// MeasureTimeWithErrorInSpan is MeasureTimeWithError that also runs f in a span named callName and
+// marks the span as failed when f returns an error.
+func MeasureTimeWithErrorInSpan[T any](ctx context.Context, callName string, metricsManager MetricsManagerContract, f func(ctx context.Context) (*T, *error)) (*T, *error) {
+	ctx, span := tracing.Tracer(nil).Start(tracing.Context(ctx), callName)
+	defer span.End()
+	start := time.Now()
+	result, err := f(ctx)
+	metricsManager.SendMeasuredTime(callName, time.Since(start))
+	if err != nil {
+		tracing.RecordError(span, *err)
+	}
+	return result, err
+}
@@ -0,0 +1,176 @@
+// Package otelmetrics records the metrics of a metrics.MetricsManagerContract as OpenTelemetry
+// instruments. It is its own module so that applications that don't use it don't depend on the
+// OpenTelemetry metric API. Importing it registers the "otel" backend with
+// metrics.ProvideConfiguredMetricsManager.
+package otelmetrics
+
+import (
+	"context"
+	"github.com/nicholaspark09/awsgorocket/config"
+	"github.com/nicholaspark09/awsgorocket/metrics"
+	"github.com/nicholaspark09/awsgorocket/tracing"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+type Options struct {
+	// MeterProvider defaults to the global one, which drops measurements until the application installs
+	// one with otel.SetMeterProvider. The application owns its exporter and shuts it down.
+	MeterProvider metric.MeterProvider
+	// DefaultDimensions are added to every measurement as attributes, as in metrics.MetricsOptions.
+	DefaultDimensions []metrics.Dimension
+	// LogSink receives SendLog and LogHandler events and defaults to stdout.
+	LogSink  metrics.LogSink
+	LogLevel slog.Level
+	// Logger receives instrument creation failures and may be nil.
+	Logger *slog.Logger
+}
+
+// MetricsManager records every metric as an OpenTelemetry instrument: counters as Float64Counter,
+// gauges as Float64Gauge and histograms and timers as Float64Histogram. Dimensions become attributes.
+type MetricsManager struct {
+	*metrics.Instruments
+	meter       metric.Meter
+	logSink     metrics.LogSink
+	logger      *slog.Logger
+	eventLogger *slog.Logger
+
+	mutex      sync.Mutex
+	counters   map[string]metric.Float64Counter
+	gauges     map[string]metric.Float64Gauge
+	histograms map[string]metric.Float64Histogram
+}
+
+func init() {
+	metrics.RegisterBackend(metrics.BackendOtel, func(provider config.ConfigProvider, options metrics.MetricsOptions) metrics.MetricsManagerContract {
+		return ProvideMetricsManager(Options{
+			DefaultDimensions: options.DefaultDimensions,
+			LogSink:           metrics.ConfiguredLogSink(provider, options),
+			LogLevel:          options.LogLevel,
+			Logger:            options.Logger,
+		})
+	})
+}
+
+func ProvideMetricsManager(options Options) *MetricsManager {
+	provider := options.MeterProvider
+	if provider == nil {
+		provider = otel.GetMeterProvider()
+	}
+	manager := &MetricsManager{
+		meter:      provider.Meter(tracing.InstrumentationName),
+		logSink:    options.LogSink,
+		logger:     utils.LoggerOrNop(options.Logger),
+		counters:   map[string]metric.Float64Counter{},
+		gauges:     map[string]metric.Float64Gauge{},
+		histograms: map[string]metric.Float64Histogram{},
+	}
+	manager.Instruments = metrics.ProvideInstruments(metrics.RecorderFunc(manager.record), options.DefaultDimensions, manager.logger)
+	if manager.logSink == nil {
+		manager.logSink = metrics.ProvideWriterLogSink(os.Stdout)
+	}
+	manager.eventLogger = slog.New(metrics.ProvideLogHandler(manager.logSink, options.LogLevel))
+	return manager
+}
+
+// SendLog writes an INFO event with tag as its tag to the log sink; OpenTelemetry logs are not used.
+func (manager *MetricsManager) SendLog(tag string, message string) {
+	manager.eventLogger.Info(message, metrics.TagAttribute, tag)
+}
+
+// LogHandler returns a slog.Handler that writes structured events to the same sink as SendLog.
+func (manager *MetricsManager) LogHandler() slog.Handler {
+	return manager.eventLogger.Handler()
+}
+
+// Flush flushes the log sink when it buffers events. Metrics are exported by the MeterProvider.
+func (manager *MetricsManager) Flush(ctx context.Context) error {
+	if flusher, ok := manager.logSink.(metrics.Flusher); ok {
+		return flusher.Flush(ctx)
+	}
+	return nil
+}
+
+func (manager *MetricsManager) Close() error {
+	if flusher, ok := manager.logSink.(metrics.Flusher); ok {
+		return flusher.Close()
+	}
+	return nil
+}
+
+func (manager *MetricsManager) record(kind metrics.MetricKind, name string, unit metrics.Unit, value float64, dimensions []metrics.Dimension) {
+	attributes := make([]attribute.KeyValue, 0, len(dimensions))
+	for _, dimension := range dimensions {
+		attributes = append(attributes, attribute.String(dimension.Name, dimension.Value))
+	}
+	measurement := metric.WithAttributes(attributes...)
+	ctx := context.Background()
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	// Instruments are keyed by unit as well, since OpenTelemetry identifies an instrument by both.
+	key := name + "\x00" + string(unit)
+	var err error
+	switch kind {
+	case metrics.KindCounter:
+		counter, ok := manager.counters[key]
+		if !ok {
+			counter, err = manager.meter.Float64Counter(name, metric.WithUnit(otelUnit(unit)))
+			manager.counters[key] = counter
+		}
+		if counter != nil {
+			counter.Add(ctx, value, measurement)
+		}
+	case metrics.KindGauge:
+		gauge, ok := manager.gauges[key]
+		if !ok {
+			gauge, err = manager.meter.Float64Gauge(name, metric.WithUnit(otelUnit(unit)))
+			manager.gauges[key] = gauge
+		}
+		if gauge != nil {
+			gauge.Record(ctx, value, measurement)
+		}
+	case metrics.KindHistogram:
+		histogram, ok := manager.histograms[key]
+		if !ok {
+			histogram, err = manager.meter.Float64Histogram(name, metric.WithUnit(otelUnit(unit)))
+			manager.histograms[key] = histogram
+		}
+		if histogram != nil {
+			histogram.Record(ctx, value, measurement)
+		}
+	}
+	if err != nil {
+		manager.logger.Warn("Error in creating instrument", "metric", name, "error", err)
+	}
+}
+
+// otelUnit converts a CloudWatch unit to the UCUM form OpenTelemetry uses.
+func otelUnit(unit metrics.Unit) string {
+	switch unit {
+	case metrics.UnitCount:
+		return "{count}"
+	case metrics.UnitPercent:
+		return "%"
+	case metrics.UnitSeconds:
+		return "s"
+	case metrics.UnitMilliseconds:
+		return "ms"
+	case metrics.UnitMicroseconds:
+		return "us"
+	case metrics.UnitBytes:
+		return "By"
+	case metrics.UnitKilobytes:
+		return "kBy"
+	case metrics.UnitCountPerSec:
+		return "{count}/s"
+	}
+	return ""
+}
+
+var _ metrics.InstrumentsContract = (*MetricsManager)(nil)
+var _ metrics.DistributionReader = (*MetricsManager)(nil)
@@ -0,0 +1,338 @@
+// Package prometheusmetrics keeps the metrics of a metrics.MetricsManagerContract in process for
+// Prometheus to scrape. It is its own module so that applications that don't use it don't depend on
+// the Prometheus client. Importing it registers the "prometheus" backend with
+// metrics.ProvideConfiguredMetricsManager, which also reads METRICS_LISTEN_ADDRESS.
+package prometheusmetrics
+
+import (
+	"context"
+	"errors"
+	"github.com/nicholaspark09/awsgorocket/config"
+	"github.com/nicholaspark09/awsgorocket/metrics"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+type Options struct {
+	// Namespace prefixes every metric name, e.g. "orders" turns Latency into orders_latency_seconds.
+	Namespace string
+	// DefaultDimensions are added to every series as labels, as in metrics.MetricsOptions.
+	DefaultDimensions []metrics.Dimension
+	// Registry defaults to a new registry holding only this manager's metrics.
+	Registry *prometheus.Registry
+	// Buckets are the upper bounds of every histogram in the exported unit, e.g. seconds for
+	// latencies. By default they suit the histogram's unit.
+	Buckets []float64
+	// ListenAddress, when set, serves the registry on /metrics at that address, e.g. ":9090".
+	ListenAddress string
+	// LogSink receives SendLog and LogHandler events and defaults to stdout.
+	LogSink  metrics.LogSink
+	LogLevel slog.Level
+	// Logger receives registration and server failures and may be nil.
+	Logger *slog.Logger
+}
+
+// family is the collector of one metric name. Its label names are the dimension names of the first
+// call; later calls leave the labels they lack empty and drop the ones the family doesn't have.
+type family struct {
+	kind       metrics.MetricKind
+	labelNames []string
+	counter    *prometheus.CounterVec
+	gauge      *prometheus.GaugeVec
+	histogram  *prometheus.HistogramVec
+	warned     bool
+}
+
+// MetricsManager keeps counters, gauges and histograms in process as Prometheus CounterVec, GaugeVec
+// and HistogramVec collectors. It is an http.Handler serving the registry, so it can be mounted on
+// /metrics.
+type MetricsManager struct {
+	*metrics.Instruments
+	options     Options
+	registry    *prometheus.Registry
+	handler     http.Handler
+	server      *http.Server
+	logSink     metrics.LogSink
+	logger      *slog.Logger
+	eventLogger *slog.Logger
+
+	mutex    sync.Mutex
+	families map[string]*family
+}
+
+func init() {
+	metrics.RegisterBackend(metrics.BackendPrometheus, func(provider config.ConfigProvider, options metrics.MetricsOptions) metrics.MetricsManagerContract {
+		repository := provider.ConfigRepository
+		if repository == nil {
+			repository = &config.ConfigRepository{}
+		}
+		return ProvideMetricsManager(Options{
+			Namespace:         options.Namespace,
+			DefaultDimensions: options.DefaultDimensions,
+			ListenAddress:     repository.GetString("METRICS_LISTEN_ADDRESS", ""),
+			LogSink:           metrics.ConfiguredLogSink(provider, options),
+			LogLevel:          options.LogLevel,
+			Logger:            options.Logger,
+		})
+	})
+}
+
+func ProvideMetricsManager(options Options) *MetricsManager {
+	manager := &MetricsManager{
+		options:  options,
+		registry: options.Registry,
+		logSink:  options.LogSink,
+		logger:   utils.LoggerOrNop(options.Logger),
+		families: map[string]*family{},
+	}
+	manager.Instruments = metrics.ProvideInstruments(metrics.RecorderFunc(manager.record), options.DefaultDimensions, manager.logger)
+	if manager.registry == nil {
+		manager.registry = prometheus.NewRegistry()
+	}
+	manager.handler = promhttp.HandlerFor(manager.registry, promhttp.HandlerOpts{})
+	if manager.logSink == nil {
+		manager.logSink = metrics.ProvideWriterLogSink(os.Stdout)
+	}
+	manager.eventLogger = slog.New(metrics.ProvideLogHandler(manager.logSink, options.LogLevel))
+	if len(options.ListenAddress) > 0 {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", manager.handler)
+		manager.server = &http.Server{Addr: options.ListenAddress, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go manager.serve()
+	}
+	return manager
+}
+
+// Registry returns the registry the metrics are served from, e.g. to add the Go runtime collectors.
+func (manager *MetricsManager) Registry() *prometheus.Registry {
+	return manager.registry
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func (manager *MetricsManager) Handler() http.Handler {
+	return manager.handler
+}
+
+func (manager *MetricsManager) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	manager.handler.ServeHTTP(writer, request)
+}
+
+// SendLog writes an INFO event with tag as its tag to the log sink.
+func (manager *MetricsManager) SendLog(tag string, message string) {
+	manager.eventLogger.Info(message, metrics.TagAttribute, tag)
+}
+
+// LogHandler returns a slog.Handler that writes structured events to the same sink as SendLog.
+func (manager *MetricsManager) LogHandler() slog.Handler {
+	return manager.eventLogger.Handler()
+}
+
+// Flush flushes the log sink when it buffers events. Metrics are only read when scraped.
+func (manager *MetricsManager) Flush(ctx context.Context) error {
+	if flusher, ok := manager.logSink.(metrics.Flusher); ok {
+		return flusher.Flush(ctx)
+	}
+	return nil
+}
+
+// Close stops the /metrics server, if any, and closes the log sink.
+func (manager *MetricsManager) Close() error {
+	var errs []error
+	if manager.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		errs = append(errs, manager.server.Shutdown(ctx))
+	}
+	if flusher, ok := manager.logSink.(metrics.Flusher); ok {
+		errs = append(errs, flusher.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (manager *MetricsManager) serve() {
+	if err := manager.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		manager.logger.Error("Error in serving metrics", "address", manager.options.ListenAddress, "error", err)
+	}
+}
+
+func (manager *MetricsManager) record(kind metrics.MetricKind, name string, unit metrics.Unit, value float64, dimensions []metrics.Dimension) {
+	unit, value = baseUnit(unit, value)
+	familyName := metricName(manager.options.Namespace, name, unit, kind)
+	labels := make(prometheus.Labels, len(dimensions))
+	for _, dimension := range dimensions {
+		labels[labelName(dimension.Name)] = dimension.Value
+	}
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	collector, ok := manager.families[familyName]
+	if !ok {
+		collector = manager.register(familyName, name, kind, unit, dimensions)
+		manager.families[familyName] = collector
+	}
+	if collector == nil {
+		return
+	}
+	if collector.kind != kind {
+		manager.logger.Warn("Dropping metric recorded as another type", "metric", familyName)
+		return
+	}
+	values := make(prometheus.Labels, len(collector.labelNames))
+	for _, label := range collector.labelNames {
+		values[label] = labels[label]
+	}
+	if !collector.warned && !sameLabels(labels, values) {
+		manager.logger.Warn("Dropping labels the metric was not first recorded with", "metric", familyName, "labels", collector.labelNames)
+		collector.warned = true
+	}
+	switch kind {
+	case metrics.KindCounter:
+		if value < 0 {
+			manager.logger.Warn("Dropping negative counter increment", "metric", familyName)
+			return
+		}
+		collector.counter.With(values).Add(value)
+	case metrics.KindGauge:
+		collector.gauge.With(values).Set(value)
+	case metrics.KindHistogram:
+		collector.histogram.With(values).Observe(value)
+	}
+}
+
+// register creates and registers the Vec of a new metric name. It returns nil when the registry
+// refuses it, e.g. because another collector already uses the name.
+func (manager *MetricsManager) register(familyName string, help string, kind metrics.MetricKind, unit metrics.Unit, dimensions []metrics.Dimension) *family {
+	created := &family{kind: kind, labelNames: make([]string, 0, len(dimensions))}
+	for _, dimension := range dimensions {
+		created.labelNames = append(created.labelNames, labelName(dimension.Name))
+	}
+	var collector prometheus.Collector
+	switch kind {
+	case metrics.KindCounter:
+		created.counter = prometheus.NewCounterVec(prometheus.CounterOpts{Name: familyName, Help: help}, created.labelNames)
+		collector = created.counter
+	case metrics.KindGauge:
+		created.gauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: familyName, Help: help}, created.labelNames)
+		collector = created.gauge
+	case metrics.KindHistogram:
+		buckets := manager.options.Buckets
+		if len(buckets) == 0 {
+			buckets = defaultBuckets(unit)
+		}
+		created.histogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: familyName, Help: help, Buckets: buckets}, created.labelNames)
+		collector = created.histogram
+	}
+	if err := manager.registry.Register(collector); err != nil {
+		manager.logger.Error("Error in registering metric", "metric", familyName, "error", err)
+		return nil
+	}
+	return created
+}
+
+func sameLabels(labels prometheus.Labels, values prometheus.Labels) bool {
+	for name, value := range labels {
+		if values[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// baseUnit converts time units to seconds, the base unit Prometheus expects.
+func baseUnit(unit metrics.Unit, value float64) (metrics.Unit, float64) {
+	switch unit {
+	case metrics.UnitMilliseconds:
+		return metrics.UnitSeconds, value / 1e3
+	case metrics.UnitMicroseconds:
+		return metrics.UnitSeconds, value / 1e6
+	}
+	return unit, value
+}
+
+// metricName follows the Prometheus naming conventions: snake case, the base unit as a suffix and
+// _total on counters, e.g. Latency in milliseconds becomes latency_seconds.
+func metricName(namespace string, name string, unit metrics.Unit, kind metrics.MetricKind) string {
+	fullName := snakeCase(name)
+	if len(namespace) > 0 {
+		fullName = snakeCase(namespace) + "_" + fullName
+	}
+	if suffix := unitSuffix(unit); len(suffix) > 0 && !strings.HasSuffix(fullName, suffix) {
+		fullName += suffix
+	}
+	if kind == metrics.KindCounter {
+		fullName += "_total"
+	}
+	if len(fullName) > 0 && unicode.IsDigit(rune(fullName[0])) {
+		fullName = "_" + fullName
+	}
+	return fullName
+}
+
+func labelName(name string) string {
+	label := snakeCase(name)
+	if len(label) > 0 && unicode.IsDigit(rune(label[0])) {
+		label = "_" + label
+	}
+	return label
+}
+
+func unitSuffix(unit metrics.Unit) string {
+	switch unit {
+	case metrics.UnitPercent:
+		return "_percent"
+	case metrics.UnitSeconds:
+		return "_seconds"
+	case metrics.UnitBytes:
+		return "_bytes"
+	case metrics.UnitKilobytes:
+		return "_kilobytes"
+	case metrics.UnitCountPerSec:
+		return "_per_second"
+	}
+	return ""
+}
+
+// snakeCase turns "ConsumedCapacity" into "consumed_capacity" and "4XXError" into "4xx_error", and
+// replaces anything that is not a letter, digit or underscore.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var builder strings.Builder
+	for i, current := range runes {
+		if !(current < unicode.MaxASCII && (unicode.IsLetter(current) || unicode.IsDigit(current))) {
+			builder.WriteByte('_')
+			continue
+		}
+		if unicode.IsUpper(current) && i > 0 {
+			previous := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				builder.WriteByte('_')
+			}
+		}
+		builder.WriteRune(unicode.ToLower(current))
+	}
+	return builder.String()
+}
+
+func defaultBuckets(unit metrics.Unit) []float64 {
+	switch unit {
+	case metrics.UnitBytes:
+		return prometheus.ExponentialBuckets(256, 4, 10)
+	case metrics.UnitPercent:
+		return prometheus.LinearBuckets(10, 10, 10)
+	}
+	// DefBuckets run from 5ms to 10s, which suits latencies in seconds.
+	return prometheus.DefBuckets
+}
+
+var _ metrics.InstrumentsContract = (*MetricsManager)(nil)
+var _ metrics.DistributionReader = (*MetricsManager)(nil)
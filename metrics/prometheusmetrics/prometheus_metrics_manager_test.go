@@ -0,0 +1,117 @@
+package prometheusmetrics
+
+import (
+	"bytes"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/nicholaspark09/awsgorocket/config"
+	"github.com/nicholaspark09/awsgorocket/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnakeCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"Latency":          "latency",
+		"ConsumedCapacity": "consumed_capacity",
+		"4XXError":         "4xx_error",
+		"HTTPRequests":     "http_requests",
+		"cache.hit-rate":   "cache_hit_rate",
+		"already_snake":    "already_snake",
+	} {
+		if got := snakeCase(name); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestMetricName(t *testing.T) {
+	cases := []struct {
+		namespace string
+		name      string
+		unit      metrics.Unit
+		kind      metrics.MetricKind
+		want      string
+	}{
+		{"Orders", "Latency", metrics.UnitSeconds, metrics.KindHistogram, "orders_latency_seconds"},
+		{"orders", "5XXError", metrics.UnitCount, metrics.KindCounter, "orders_5xx_error_total"},
+		{"", "4XXError", metrics.UnitCount, metrics.KindCounter, "_4xx_error_total"},
+		{"orders", "PayloadBytes", metrics.UnitBytes, metrics.KindGauge, "orders_payload_bytes"},
+	}
+	for _, test := range cases {
+		if got := metricName(test.namespace, test.name, test.unit, test.kind); got != test.want {
+			t.Errorf("metricName(%q, %q) = %q, want %q", test.namespace, test.name, got, test.want)
+		}
+	}
+}
+
+func gather(t *testing.T, manager *MetricsManager) map[string]*dto.MetricFamily {
+	families, err := manager.Registry().Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string]*dto.MetricFamily{}
+	for _, family := range families {
+		byName[family.GetName()] = family
+	}
+	return byName
+}
+
+func TestTimersAreExportedInSeconds(t *testing.T) {
+	manager := ProvideMetricsManager(Options{Namespace: "orders"})
+	manager.SendMeasuredTime("GetOrder", 250*time.Millisecond)
+	family, ok := gather(t, manager)["orders_latency_seconds"]
+	if !ok {
+		t.Fatalf("orders_latency_seconds not gathered: %v", gather(t, manager))
+	}
+	histogram := family.GetMetric()[0].GetHistogram()
+	if histogram.GetSampleCount() != 1 || histogram.GetSampleSum() != 0.25 {
+		t.Fatalf("histogram = %d samples summing to %v, want 1 summing to 0.25", histogram.GetSampleCount(), histogram.GetSampleSum())
+	}
+	label := family.GetMetric()[0].GetLabel()[0]
+	if label.GetName() != "operation" || label.GetValue() != "GetOrder" {
+		t.Fatalf("label = %s=%s, want operation=GetOrder", label.GetName(), label.GetValue())
+	}
+}
+
+func TestCollectorsAreDescribed(t *testing.T) {
+	manager := ProvideMetricsManager(Options{Namespace: "orders"})
+	manager.Send500Error("GetOrder", 503, "unavailable")
+	// A second collector with the same name but other labels is only refused when the first one
+	// describes itself.
+	err := manager.Registry().Register(prometheus.NewCounterVec(prometheus.CounterOpts{Name: "orders_5xx_error_total", Help: "other"}, []string{"other"}))
+	if err == nil {
+		t.Fatal("registering a clashing collector succeeded, so the manager's collectors are unchecked")
+	}
+	counter := gather(t, manager)["orders_5xx_error_total"].GetMetric()[0].GetCounter()
+	if counter.GetValue() != 1 {
+		t.Fatalf("counter = %v, want 1", counter.GetValue())
+	}
+}
+
+func TestLaterLabelsAreDropped(t *testing.T) {
+	var output bytes.Buffer
+	manager := ProvideMetricsManager(Options{Logger: slog.New(slog.NewTextHandler(&output, nil))})
+	manager.Counter("Orders", 1, metrics.Dim("Operation", "Create"))
+	manager.Counter("Orders", 1, metrics.Dim("Operation", "Create"), metrics.Dim("Outcome", "success"))
+	metric := gather(t, manager)["orders_total"].GetMetric()
+	if len(metric) != 1 || metric[0].GetCounter().GetValue() != 2 {
+		t.Fatalf("orders_total = %v, want one series counting 2", metric)
+	}
+	if !strings.Contains(output.String(), "Dropping labels") {
+		t.Fatalf("log = %q, want a warning about the dropped label", output.String())
+	}
+}
+
+func TestConfiguredBackend(t *testing.T) {
+	manager := metrics.ProvideConfiguredMetricsManager(config.ConfigProvider{
+		SdkConfig:        aws.Config{Region: "us-west-2"},
+		ConfigRepository: config.ProvideConfigRepository(config.ProvideMapSource("test", map[string]string{"METRICS_BACKEND": "prometheus"})),
+	}, "orders")
+	if _, ok := manager.(*MetricsManager); !ok {
+		t.Fatalf("METRICS_BACKEND=prometheus built %T", manager)
+	}
+}
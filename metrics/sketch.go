@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultRelativeAccuracy keeps every quantile within 1% of the true value.
+const DefaultRelativeAccuracy = 0.01
+
+// Sketch is a DDSketch-style histogram: values land in logarithmic buckets whose width grows with the
+// value, so quantiles stay within a fixed relative error while memory only grows with the value range.
+// A Sketch is not safe for concurrent use.
+type Sketch struct {
+	gamma     float64
+	logGamma  float64
+	buckets   map[int]float64
+	zeroCount float64
+	count     float64
+	sum       float64
+	min       float64
+	max       float64
+}
+
+// NewSketch creates a sketch with the given relative accuracy; zero or less uses DefaultRelativeAccuracy.
+func NewSketch(relativeAccuracy float64) *Sketch {
+	if relativeAccuracy <= 0 || relativeAccuracy >= 1 {
+		relativeAccuracy = DefaultRelativeAccuracy
+	}
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+	return &Sketch{
+		gamma:    gamma,
+		logGamma: math.Log(gamma),
+		buckets:  map[int]float64{},
+		min:      math.Inf(1),
+		max:      math.Inf(-1),
+	}
+}
+
+// Add records one observation. Values at or below zero share a single zero bucket.
+func (sketch *Sketch) Add(value float64) {
+	if math.IsNaN(value) {
+		return
+	}
+	if value <= 0 {
+		sketch.zeroCount++
+	} else {
+		sketch.buckets[int(math.Ceil(math.Log(value)/sketch.logGamma))]++
+	}
+	sketch.count++
+	sketch.sum += value
+	sketch.min = math.Min(sketch.min, value)
+	sketch.max = math.Max(sketch.max, value)
+}
+
+func (sketch *Sketch) Count() float64 {
+	return sketch.count
+}
+
+func (sketch *Sketch) Sum() float64 {
+	return sketch.sum
+}
+
+func (sketch *Sketch) Min() float64 {
+	if sketch.count == 0 {
+		return 0
+	}
+	return sketch.min
+}
+
+func (sketch *Sketch) Max() float64 {
+	if sketch.count == 0 {
+		return 0
+	}
+	return sketch.max
+}
+
+// Quantile returns the value at q, between 0 and 1, or 0 for an empty sketch.
+func (sketch *Sketch) Quantile(q float64) float64 {
+	if sketch.count == 0 {
+		return 0
+	}
+	// Nearest rank: the smallest value with at least q of the observations at or below it.
+	rank := math.Max(0, math.Ceil(math.Max(0, math.Min(1, q))*sketch.count)-1)
+	if rank < sketch.zeroCount {
+		return math.Max(0, sketch.min)
+	}
+	seen := sketch.zeroCount
+	for _, index := range sketch.sortedIndexes() {
+		seen += sketch.buckets[index]
+		if seen > rank {
+			return math.Min(sketch.max, math.Max(sketch.min, sketch.bucketValue(index)))
+		}
+	}
+	return sketch.max
+}
+
+// Buckets returns one representative value per non-empty bucket, in ascending order, with its count.
+// This is the shape PutMetricData expects in Values and Counts.
+func (sketch *Sketch) Buckets() ([]float64, []float64) {
+	values := make([]float64, 0, len(sketch.buckets)+1)
+	counts := make([]float64, 0, len(sketch.buckets)+1)
+	if sketch.zeroCount > 0 {
+		values = append(values, math.Max(0, sketch.min))
+		counts = append(counts, sketch.zeroCount)
+	}
+	for _, index := range sketch.sortedIndexes() {
+		values = append(values, sketch.bucketValue(index))
+		counts = append(counts, sketch.buckets[index])
+	}
+	return values, counts
+}
+
+func (sketch *Sketch) sortedIndexes() []int {
+	indexes := make([]int, 0, len(sketch.buckets))
+	for index := range sketch.buckets {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// bucketValue is the point in bucket index with the same relative distance to both of its edges.
+func (sketch *Sketch) bucketValue(index int) float64 {
+	return 2 * math.Pow(sketch.gamma, float64(index)) / (sketch.gamma + 1)
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+	"testing"
+)
+
+func TestSketchQuantilesStayWithinRelativeAccuracy(t *testing.T) {
+	random := rand.New(rand.NewPCG(1, 2))
+	sketch := NewSketch(DefaultRelativeAccuracy)
+	values := make([]float64, 0, 100000)
+	for i := 0; i < cap(values); i++ {
+		// Latency-like values spread over several orders of magnitude.
+		value := math.Exp(random.NormFloat64()*1.5 + 4)
+		values = append(values, value)
+		sketch.Add(value)
+	}
+	sort.Float64s(values)
+	for _, q := range []float64{0, 0.01, 0.25, 0.5, 0.9, 0.99, 0.999, 1} {
+		rank := int(math.Max(0, math.Ceil(q*float64(len(values)))-1))
+		exact := values[rank]
+		estimate := sketch.Quantile(q)
+		if math.Abs(estimate-exact) > DefaultRelativeAccuracy*exact {
+			t.Errorf("quantile %v = %v, exact %v, more than %v off", q, estimate, exact, DefaultRelativeAccuracy)
+		}
+	}
+}
+
+func TestSketchBucketsKeepEveryObservation(t *testing.T) {
+	sketch := NewSketch(DefaultRelativeAccuracy)
+	for _, value := range []float64{0, -1, 0.5, 1, 1, 2, 1000} {
+		sketch.Add(value)
+	}
+	values, counts := sketch.Buckets()
+	total := 0.0
+	for i, count := range counts {
+		total += count
+		if i > 0 && values[i] <= values[i-1] {
+			t.Fatalf("bucket values are not ascending: %v", values)
+		}
+	}
+	if total != sketch.Count() || sketch.Count() != 7 {
+		t.Fatalf("buckets hold %v observations, sketch counted %v, want 7", total, sketch.Count())
+	}
+	if sketch.Min() != -1 || sketch.Max() != 1000 {
+		t.Fatalf("min/max = %v/%v", sketch.Min(), sketch.Max())
+	}
+}
@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"time"
+)
+
+type logContextKey int
+
+const (
+	requestIdKey logContextKey = iota
+	traceIdKey
+)
+
+// The attribute that LogHandler lifts out of the fields into the event's tag.
+const TagAttribute = "tag"
+
+// WithRequestId stores a request ID that LogHandler adds to every event logged with ctx.
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey, requestId)
+}
+
+// WithTraceId stores a trace ID that LogHandler adds to every event logged with ctx. Without one,
+// the Lambda X-Ray trace header is used when present.
+func WithTraceId(ctx context.Context, traceId string) context.Context {
+	return context.WithValue(ctx, traceIdKey, traceId)
+}
+
+// LogEvent is the JSON document written for every log call.
+type LogEvent struct {
+	Timestamp time.Time      `json:"timestamp"`
+	Level     string         `json:"level"`
+	Tag       string         `json:"tag,omitempty"`
+	Message   string         `json:"message"`
+	RequestId string         `json:"request_id,omitempty"`
+	TraceId   string         `json:"trace_id,omitempty"`
+	Fields    map[string]any `json:"fields,omitempty"`
+}
+
+// LogHandler is a slog.Handler that encodes records as LogEvent JSON and hands them to a LogSink.
+type LogHandler struct {
+	sink   LogSink
+	level  slog.Leveler
+	attrs  []groupedAttrs
+	groups []string
+}
+
+// groupedAttrs are attributes added by WithAttrs while groups were open.
+type groupedAttrs struct {
+	groups []string
+	attrs  []slog.Attr
+}
+
+// ProvideLogHandler logs records at level and above; a nil level means slog.LevelInfo.
+func ProvideLogHandler(sink LogSink, level slog.Leveler) *LogHandler {
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	return &LogHandler{sink: sink, level: level}
+}
+
+func (handler *LogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= handler.level.Level()
+}
+
+func (handler *LogHandler) Handle(ctx context.Context, record slog.Record) error {
+	event := LogEvent{
+		Timestamp: record.Time.UTC(),
+		Level:     record.Level.String(),
+		Message:   record.Message,
+		Fields:    map[string]any{},
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	if ctx != nil {
+		event.RequestId, _ = ctx.Value(requestIdKey).(string)
+		event.TraceId, _ = ctx.Value(traceIdKey).(string)
+	}
+	if len(event.TraceId) == 0 {
+		event.TraceId = os.Getenv("_X_AMZN_TRACE_ID")
+	}
+	for _, chunk := range handler.attrs {
+		fields := nestedFields(event.Fields, chunk.groups)
+		for _, attr := range chunk.attrs {
+			addField(fields, &event, attr)
+		}
+	}
+	fields := nestedFields(event.Fields, handler.groups)
+	record.Attrs(func(attr slog.Attr) bool {
+		addField(fields, &event, attr)
+		return true
+	})
+	if len(event.Fields) == 0 {
+		event.Fields = nil
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return handler.sink.WriteEvent(event.Timestamp, line)
+}
+
+func (handler *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *handler
+	next.attrs = append(append([]groupedAttrs{}, handler.attrs...), groupedAttrs{groups: handler.groups, attrs: attrs})
+	return &next
+}
+
+func (handler *LogHandler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return handler
+	}
+	next := *handler
+	next.groups = append(append([]string{}, handler.groups...), name)
+	return &next
+}
+
+func nestedFields(fields map[string]any, groups []string) map[string]any {
+	for _, group := range groups {
+		nested, ok := fields[group].(map[string]any)
+		if !ok {
+			nested = map[string]any{}
+			fields[group] = nested
+		}
+		fields = nested
+	}
+	return fields
+}
+
+func addField(fields map[string]any, event *LogEvent, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if attr.Key == TagAttribute && value.Kind() == slog.KindString {
+		event.Tag = value.String()
+		return
+	}
+	if value.Kind() == slog.KindGroup {
+		group := fields
+		if len(attr.Key) > 0 {
+			group = map[string]any{}
+			fields[attr.Key] = group
+		}
+		for _, nested := range value.Group() {
+			addField(group, event, nested)
+		}
+		return
+	}
+	if len(attr.Key) == 0 {
+		return
+	}
+	switch value.Kind() {
+	case slog.KindDuration:
+		fields[attr.Key] = value.Duration().String()
+	case slog.KindTime:
+		fields[attr.Key] = value.Time().UTC()
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			fields[attr.Key] = err.Error()
+		} else {
+			fields[attr.Key] = value.Any()
+		}
+	default:
+		fields[attr.Key] = value.Any()
+	}
+}
@@ -0,0 +1,63 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"github.com/nicholaspark09/awsgorocket/metrics"
+	response "github.com/nicholaspark09/awsgorocket/model"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"net/http"
+	"time"
+)
+
+// InstrumentedNetworkManager records the outcome of every request of the manager it wraps with
+// metrics.RecordOutcome. Responses of 400 and above fail with their status code, and requests that got
+// no complete response fail as metrics.ErrNetwork. Operations are named "<name>.Get" and "<name>.Post".
+type InstrumentedNetworkManager[T any] struct {
+	manager        NetworkManager[T]
+	metricsManager metrics.MetricsManagerContract
+	name           string
+}
+
+func ProvideInstrumentedNetworkManager[T any](manager NetworkManager[T], metricsManager metrics.MetricsManagerContract, name string) InstrumentedNetworkManager[T] {
+	return InstrumentedNetworkManager[T]{
+		manager:        manager,
+		metricsManager: metricsManager,
+		name:           name,
+	}
+}
+
+func (instrumented InstrumentedNetworkManager[T]) Get() response.Response[T] {
+	return instrumented.GetWithContext(context.Background())
+}
+
+func (instrumented InstrumentedNetworkManager[T]) Post(jsonBody []byte) response.Response[T] {
+	return instrumented.PostWithContext(context.Background(), jsonBody)
+}
+
+func (instrumented InstrumentedNetworkManager[T]) GetWithContext(ctx context.Context) response.Response[T] {
+	start := time.Now()
+	result := GetWithContext(ctx, instrumented.manager)
+	instrumented.record("Get", start, result)
+	return result
+}
+
+func (instrumented InstrumentedNetworkManager[T]) PostWithContext(ctx context.Context, jsonBody []byte) response.Response[T] {
+	start := time.Now()
+	result := PostWithContext(ctx, instrumented.manager, jsonBody)
+	instrumented.record("Post", start, result)
+	return result
+}
+
+func (instrumented InstrumentedNetworkManager[T]) record(method string, start time.Time, result response.Response[T]) {
+	operation := instrumented.name + "." + method
+	var err error
+	switch {
+	case result.StatusCode >= http.StatusBadRequest:
+		err = utils.GenericError{Message: result.Message, StatusCode: result.StatusCode}
+	case result.Error != nil:
+		// Below 400 an error is a request that failed to send or a body that failed to read.
+		err = fmt.Errorf("%w: %w", metrics.ErrNetwork, *result.Error)
+	}
+	metrics.RecordOutcome(instrumented.metricsManager, operation, time.Since(start), err)
+}
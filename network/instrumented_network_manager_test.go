@@ -0,0 +1,87 @@
+package network
+
+import (
+	"github.com/nicholaspark09/awsgorocket/metrics"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// countingManager keeps the counters it is sent by name and dimensions, and the status codes sent to
+// Send400Error and Send500Error.
+type countingManager struct {
+	counters    map[string]float64
+	statusCodes []int
+}
+
+func (manager *countingManager) SendMeasuredTime(callName string, time time.Duration) {}
+
+func (manager *countingManager) SendLog(tag string, message string) {}
+
+func (manager *countingManager) Send500Error(callName string, statusCode int, message string) {
+	manager.statusCodes = append(manager.statusCodes, statusCode)
+}
+
+func (manager *countingManager) Send400Error(callName string, statusCode int, message string) {
+	manager.statusCodes = append(manager.statusCodes, statusCode)
+}
+
+func (manager *countingManager) Counter(name string, value float64, dimensions ...metrics.Dimension) {
+	key := name
+	for _, dimension := range dimensions {
+		key += " " + dimension.Name + "=" + dimension.Value
+	}
+	manager.counters[key] += value
+}
+
+func (manager *countingManager) Gauge(name string, value float64, unit metrics.Unit, dimensions ...metrics.Dimension) {
+}
+
+func (manager *countingManager) Histogram(name string, value float64, unit metrics.Unit, dimensions ...metrics.Dimension) {
+}
+
+func (manager *countingManager) Timer(name string, duration time.Duration, dimensions ...metrics.Dimension) {
+}
+
+func instrumentedGet(handler http.HandlerFunc, closed bool) *countingManager {
+	server := httptest.NewServer(handler)
+	if closed {
+		server.Close()
+	} else {
+		defer server.Close()
+	}
+	counting := &countingManager{counters: map[string]float64{}}
+	ProvideInstrumentedNetworkManager(ProvideNetworkManager[greeting](server.URL, nil, nil, nil), counting, "Greetings").Get()
+	return counting
+}
+
+func TestInstrumentedNetworkManagerCountsRequestFailuresAsNetwork(t *testing.T) {
+	counting := instrumentedGet(http.NotFound, true)
+	if counting.counters["Failure Operation=Greetings.Get"] != 1 || counting.counters["Errors Operation=Greetings.Get ErrorClass=Network"] != 1 {
+		t.Fatalf("counters = %v, want a Network failure", counting.counters)
+	}
+	if len(counting.statusCodes) != 0 {
+		t.Fatalf("status codes = %v, want no 4XXError or 5XXError without a response", counting.statusCodes)
+	}
+}
+
+func TestInstrumentedNetworkManagerCountsUnreadBodiesAsFailures(t *testing.T) {
+	counting := instrumentedGet(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Length", "100")
+		writer.Write([]byte(`{"message":`))
+	}, false)
+	if counting.counters["Success Operation=Greetings.Get"] != 0 || counting.counters["Errors Operation=Greetings.Get ErrorClass=Network"] != 1 {
+		t.Fatalf("counters = %v, want a 200 with a truncated body counted as a Network failure", counting.counters)
+	}
+}
+
+func TestInstrumentedNetworkManagerCountsErrorStatusCodes(t *testing.T) {
+	counting := instrumentedGet(http.NotFound, false)
+	if counting.counters["Errors Operation=Greetings.Get ErrorClass=NotFound"] != 1 {
+		t.Fatalf("counters = %v, want a NotFound failure", counting.counters)
+	}
+	if len(counting.statusCodes) != 1 || counting.statusCodes[0] != http.StatusNotFound {
+		t.Fatalf("status codes = %v, want 404", counting.statusCodes)
+	}
+}
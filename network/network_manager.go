@@ -2,22 +2,28 @@ package network
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	response "github.com/nicholaspark09/awsgorocket/model"
+	"github.com/nicholaspark09/awsgorocket/tracing"
+	"github.com/nicholaspark09/awsgorocket/utils"
+	"go.opentelemetry.io/otel/trace"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 )
 
 type NetworkManager[T any] struct {
-	endpoint    string
-	params      map[string]string
-	apiKey      *string
-	contentType *string
-	client      http.Client
+	endpoint       string
+	params         map[string]string
+	apiKey         *string
+	contentType    *string
+	client         http.Client
+	logger         *slog.Logger
+	tracerProvider trace.TracerProvider
 }
 
 func ProvideNetworkManager[T any](endpoint string, params map[string]string, apiKey *string, contentType *string) NetworkManager[T] {
@@ -30,6 +36,29 @@ func ProvideNetworkManager[T any](endpoint string, params map[string]string, api
 	}
 }
 
+// WithLogger returns a copy of the manager that logs to logger; without one nothing is logged.
+func (manager NetworkManager[T]) WithLogger(logger *slog.Logger) NetworkManager[T] {
+	manager.logger = logger
+	return manager
+}
+
+// WithTracerProvider returns a copy of the manager that creates spans with provider instead of the
+// global one.
+func (manager NetworkManager[T]) WithTracerProvider(provider trace.TracerProvider) NetworkManager[T] {
+	manager.tracerProvider = provider
+	return manager
+}
+
+func (manager *NetworkManager[T]) log() *slog.Logger {
+	return utils.LoggerOrNop(manager.logger).With("endpoint", manager.endpoint)
+}
+
+// do sends req in a client span under the span in the request's context and propagates the trace
+// context to the server.
+func (manager *NetworkManager[T]) do(req *http.Request) (*http.Response, error) {
+	return tracing.Do(req.Context(), manager.tracerProvider, &manager.client, req)
+}
+
 func (manager *NetworkManager[T]) GetEndpoint() (string, *error) {
 	parsedUrl, err := url.Parse(manager.endpoint)
 	if err != nil {
@@ -45,9 +74,15 @@ func (manager *NetworkManager[T]) GetEndpoint() (string, *error) {
 }
 
 func Get[T any](manager NetworkManager[T]) response.Response[T] {
+	return GetWithContext(context.Background(), manager)
+}
+
+// GetWithContext makes the request with ctx, so it is cancelled with ctx and its span is a child of the
+// span in ctx. A request that gets no response returns a StatusCode of 0 with Error set.
+func GetWithContext[T any](ctx context.Context, manager NetworkManager[T]) response.Response[T] {
 	formedEndpoint, queryError := manager.GetEndpoint()
 	if queryError != nil {
-		log.Println("Error in parsing Query")
+		manager.log().Error("Error in parsing query", "error", *queryError)
 		return response.Response[T]{
 			Error:      queryError,
 			Message:    fmt.Sprintf("Error in parsing query: %s", errors.Unwrap(*queryError).Error()),
@@ -55,7 +90,7 @@ func Get[T any](manager NetworkManager[T]) response.Response[T] {
 			StatusCode: 400,
 		}
 	}
-	req, err := http.NewRequest("GET", formedEndpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", formedEndpoint, nil)
 	if err != nil {
 		return response.Response[T]{
 			Error:      &err,
@@ -72,20 +107,20 @@ func Get[T any](manager NetworkManager[T]) response.Response[T] {
 	if manager.apiKey != nil {
 		req.Header.Set("x-api-key", *manager.apiKey)
 	}
-	clientResponse, err := manager.client.Do(req)
+	clientResponse, err := manager.do(req)
 	if err != nil {
-		log.Printf("Error in making api request: %s", err.Error())
+		manager.log().Error("Error in making api request", "error", err)
 		return response.Response[T]{
-			StatusCode: clientResponse.StatusCode,
+			StatusCode: 0,
 			Data:       nil,
 			Error:      &err,
-			Message:    errors.Unwrap(err).Error(),
+			Message:    err.Error(),
 		}
 	}
 	defer clientResponse.Body.Close()
 	if clientResponse.StatusCode != http.StatusOK {
 		errorMessage := fmt.Sprintf("Error with the request call: %d", clientResponse.StatusCode)
-		log.Println(errorMessage)
+		manager.log().Warn("Error with the request call", "status", clientResponse.StatusCode)
 		return response.Response[T]{
 			StatusCode: clientResponse.StatusCode,
 			Data:       nil,
@@ -96,6 +131,7 @@ func Get[T any](manager NetworkManager[T]) response.Response[T] {
 	responseBody, err := ioutil.ReadAll(clientResponse.Body)
 	if err != nil {
 		errorMessage := fmt.Sprintf("Error in reading api request: %s", err.Error())
+		manager.log().Error("Error in reading api request", "status", clientResponse.StatusCode, "error", err)
 		return response.Response[T]{
 			StatusCode: clientResponse.StatusCode,
 			Data:       nil,
@@ -106,7 +142,7 @@ func Get[T any](manager NetworkManager[T]) response.Response[T] {
 	var result T
 	jsonError := json.Unmarshal(responseBody, &result)
 	if jsonError != nil {
-		log.Printf("Error in unmarshalling api request: %s", jsonError.Error())
+		manager.log().Error("Error in unmarshalling api request", "status", clientResponse.StatusCode, "error", jsonError)
 		return response.Response[T]{
 			StatusCode: 500,
 			Data:       nil,
@@ -123,9 +159,14 @@ func Get[T any](manager NetworkManager[T]) response.Response[T] {
 }
 
 func Post[T any](manager NetworkManager[T], jsonBody []byte) response.Response[T] {
+	return PostWithContext(context.Background(), manager, jsonBody)
+}
+
+// PostWithContext makes the request with ctx, like GetWithContext.
+func PostWithContext[T any](ctx context.Context, manager NetworkManager[T], jsonBody []byte) response.Response[T] {
 	formedEndpoint, queryError := manager.GetEndpoint()
 	if queryError != nil {
-		log.Println("Error in parsing Query")
+		manager.log().Error("Error in parsing query", "error", *queryError)
 		return response.Response[T]{
 			Error:      queryError,
 			Message:    fmt.Sprintf("Error in parsing query: %s", errors.Unwrap(*queryError).Error()),
@@ -133,7 +174,7 @@ func Post[T any](manager NetworkManager[T], jsonBody []byte) response.Response[T
 			StatusCode: 400,
 		}
 	}
-	req, err := http.NewRequest("POST", formedEndpoint, bytes.NewReader(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", formedEndpoint, bytes.NewReader(jsonBody))
 	if err != nil {
 		return response.Response[T]{
 			Error:      &err,
@@ -150,20 +191,20 @@ func Post[T any](manager NetworkManager[T], jsonBody []byte) response.Response[T
 	if manager.apiKey != nil {
 		req.Header.Set("x-api-key", *manager.apiKey)
 	}
-	clientResponse, err := manager.client.Do(req)
+	clientResponse, err := manager.do(req)
 	if err != nil {
-		log.Printf("Error in making api request: %s", err.Error())
+		manager.log().Error("Error in making api request", "error", err)
 		return response.Response[T]{
-			StatusCode: clientResponse.StatusCode,
+			StatusCode: 0,
 			Data:       nil,
 			Error:      &err,
-			Message:    errors.Unwrap(err).Error(),
+			Message:    err.Error(),
 		}
 	}
 	defer clientResponse.Body.Close()
 	if clientResponse.StatusCode != http.StatusOK {
 		errorMessage := fmt.Sprintf("Error with the request call: %d", clientResponse.StatusCode)
-		log.Println(errorMessage)
+		manager.log().Warn("Error with the request call", "status", clientResponse.StatusCode)
 		return response.Response[T]{
 			StatusCode: clientResponse.StatusCode,
 			Data:       nil,
@@ -173,12 +214,19 @@ func Post[T any](manager NetworkManager[T], jsonBody []byte) response.Response[T
 	}
 	responseBody, err := ioutil.ReadAll(clientResponse.Body)
 	if err != nil {
-		log.Printf("Error in reading api request: %s", err.Error())
+		errorMessage := fmt.Sprintf("Error in reading api request: %s", err.Error())
+		manager.log().Error("Error in reading api request", "status", clientResponse.StatusCode, "error", err)
+		return response.Response[T]{
+			StatusCode: clientResponse.StatusCode,
+			Data:       nil,
+			Error:      &err,
+			Message:    errorMessage,
+		}
 	}
 	var result T
 	jsonError := json.Unmarshal(responseBody, &result)
 	if jsonError != nil {
-		log.Printf("Error in unmarshalling api request: %s", jsonError.Error())
+		manager.log().Error("Error in unmarshalling api request", "status", clientResponse.StatusCode, "error", jsonError)
 		return response.Response[T]{
 			StatusCode: 500,
 			Data:       nil,
@@ -0,0 +1,47 @@
+package network
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type greeting struct {
+	Message string `json:"message"`
+}
+
+func TestGetWithContextJoinsTheCallersTrace(t *testing.T) {
+	traceId := trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceId,
+		SpanID:     trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceFlags: trace.FlagsSampled,
+	})
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		traceparent = request.Header.Get("traceparent")
+		writer.Write([]byte(`{"message":"hello"}`))
+	}))
+	defer server.Close()
+
+	manager := ProvideNetworkManager[greeting](server.URL, nil, nil, nil)
+	result := GetWithContext(trace.ContextWithSpanContext(context.Background(), parent), manager)
+	if result.Data == nil || result.Data.Message != "hello" {
+		t.Fatalf("response = %+v, want hello", result)
+	}
+	if !strings.Contains(traceparent, traceId.String()) {
+		t.Fatalf("traceparent = %q, want trace %s", traceparent, traceId)
+	}
+}
+
+func TestGetReportsFailedRequestsWithoutAStatusCode(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	result := Get(ProvideNetworkManager[greeting](server.URL, nil, nil, nil))
+	if result.StatusCode != 0 || result.Error == nil || result.Data != nil {
+		t.Fatalf("response = %+v, want no status code and the request error", result)
+	}
+}
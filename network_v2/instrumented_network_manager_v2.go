@@ -0,0 +1,50 @@
+package network_v2
+
+import (
+	"context"
+	"github.com/nicholaspark09/awsgorocket/metrics"
+	"time"
+)
+
+// InstrumentedNetworkManagerV2 records the outcome of every request of the manager it wraps with
+// metrics.RecordOutcome. Operations are named "<name>.Get" and "<name>.Post".
+type InstrumentedNetworkManagerV2[T any] struct {
+	manager        NetworkManagerV2[T]
+	metricsManager metrics.MetricsManagerContract
+	name           string
+}
+
+func ProvideInstrumentedNetworkManagerV2[T any](manager NetworkManagerV2[T], metricsManager metrics.MetricsManagerContract, name string) InstrumentedNetworkManagerV2[T] {
+	return InstrumentedNetworkManagerV2[T]{
+		manager:        manager,
+		metricsManager: metricsManager,
+		name:           name,
+	}
+}
+
+func (instrumented InstrumentedNetworkManagerV2[T]) Get() (*T, error) {
+	return instrumented.GetWithContext(context.Background())
+}
+
+func (instrumented InstrumentedNetworkManagerV2[T]) Post(json []byte) (*T, error) {
+	return instrumented.PostWithContext(context.Background(), json)
+}
+
+func (instrumented InstrumentedNetworkManagerV2[T]) GetWithContext(ctx context.Context) (*T, error) {
+	start := time.Now()
+	result, err := GetWithContext(ctx, instrumented.manager)
+	instrumented.record("Get", start, err)
+	return result, err
+}
+
+func (instrumented InstrumentedNetworkManagerV2[T]) PostWithContext(ctx context.Context, json []byte) (*T, error) {
+	start := time.Now()
+	result, err := PostWithContext(ctx, instrumented.manager, json)
+	instrumented.record("Post", start, err)
+	return result, err
+}
+
+func (instrumented InstrumentedNetworkManagerV2[T]) record(method string, start time.Time, err error) {
+	operation := instrumented.name + "." + method
+	metrics.RecordOutcome(instrumented.metricsManager, operation, time.Since(start), err)
+}
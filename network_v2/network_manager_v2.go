@@ -2,21 +2,26 @@ package network_v2
 
 import (
 	"bytes"
+	"context"
 	json2 "encoding/json"
 	"fmt"
+	"github.com/nicholaspark09/awsgorocket/tracing"
 	"github.com/nicholaspark09/awsgorocket/utils"
+	"go.opentelemetry.io/otel/trace"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 )
 
 type NetworkManagerV2[T any] struct {
-	endpoint    string
-	params      map[string]string
-	apiKey      *string
-	contentType *string
-	client      http.Client
+	endpoint       string
+	params         map[string]string
+	apiKey         *string
+	contentType    *string
+	client         http.Client
+	logger         *slog.Logger
+	tracerProvider trace.TracerProvider
 }
 
 func ProvideNetworkManagerV2[T any](endpoint string, params map[string]string, apiKey *string, contentType *string) NetworkManagerV2[T] {
@@ -29,6 +34,29 @@ func ProvideNetworkManagerV2[T any](endpoint string, params map[string]string, a
 	}
 }
 
+// WithLogger returns a copy of the manager that logs to logger; without one nothing is logged.
+func (manager NetworkManagerV2[T]) WithLogger(logger *slog.Logger) NetworkManagerV2[T] {
+	manager.logger = logger
+	return manager
+}
+
+// WithTracerProvider returns a copy of the manager that creates spans with provider instead of the
+// global one.
+func (manager NetworkManagerV2[T]) WithTracerProvider(provider trace.TracerProvider) NetworkManagerV2[T] {
+	manager.tracerProvider = provider
+	return manager
+}
+
+func (manager *NetworkManagerV2[T]) log() *slog.Logger {
+	return utils.LoggerOrNop(manager.logger).With("endpoint", manager.endpoint)
+}
+
+// do sends req in a client span under the span in the request's context and propagates the trace
+// context to the server.
+func (manager *NetworkManagerV2[T]) do(req *http.Request) (*http.Response, error) {
+	return tracing.Do(req.Context(), manager.tracerProvider, &manager.client, req)
+}
+
 func (manager *NetworkManagerV2[T]) GetEndpoint() (string, *error) {
 	parsedUrl, err := url.Parse(manager.endpoint)
 	if err != nil {
@@ -44,12 +72,17 @@ func (manager *NetworkManagerV2[T]) GetEndpoint() (string, *error) {
 }
 
 func Post[T any](manager NetworkManagerV2[T], json []byte) (*T, error) {
+	return PostWithContext(context.Background(), manager, json)
+}
+
+// PostWithContext makes the request with ctx, like GetWithContext.
+func PostWithContext[T any](ctx context.Context, manager NetworkManagerV2[T], json []byte) (*T, error) {
 	formedEndpoint, queryError := manager.GetEndpoint()
 	if queryError != nil {
-		log.Println("Error in parsing Query")
+		manager.log().Error("Error in parsing query", "error", *queryError)
 		return nil, *queryError
 	}
-	req, err := http.NewRequest("POST", formedEndpoint, bytes.NewReader(json))
+	req, err := http.NewRequestWithContext(ctx, "POST", formedEndpoint, bytes.NewReader(json))
 	if err != nil {
 		return nil, err
 	}
@@ -61,14 +94,15 @@ func Post[T any](manager NetworkManagerV2[T], json []byte) (*T, error) {
 	if manager.apiKey != nil {
 		req.Header.Set("x-api-key", *manager.apiKey)
 	}
-	response, err := manager.client.Do(req)
+	response, err := manager.do(req)
 	if err != nil {
-		log.Printf("Error in making api request: %s", err.Error())
+		manager.log().Error("Error in making api request", "error", err)
+		return nil, err
 	}
 	defer response.Body.Close()
 	if response.StatusCode != http.StatusOK {
 		errorMessage := fmt.Sprintf("Error with the request call: %d", response.StatusCode)
-		log.Println(errorMessage)
+		manager.log().Warn("Error with the request call", "status", response.StatusCode)
 		return nil, utils.GenericError{
 			Message:    errorMessage,
 			StatusCode: response.StatusCode,
@@ -76,24 +110,31 @@ func Post[T any](manager NetworkManagerV2[T], json []byte) (*T, error) {
 	}
 	responseBody, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		log.Printf("Error in reading api request: %s", err.Error())
+		manager.log().Error("Error in reading api request", "status", response.StatusCode, "error", err)
+		return nil, err
 	}
 	var result T
 	jsonError := json2.Unmarshal(responseBody, &result)
 	if jsonError != nil {
-		log.Printf("Error in unmarshalling api request: %s", jsonError.Error())
+		manager.log().Error("Error in unmarshalling api request", "status", response.StatusCode, "error", jsonError)
 		return nil, jsonError
 	}
 	return &result, nil
 }
 
 func Get[T any](manager NetworkManagerV2[T]) (*T, error) {
+	return GetWithContext(context.Background(), manager)
+}
+
+// GetWithContext makes the request with ctx, so it is cancelled with ctx and its span is a child of the
+// span in ctx.
+func GetWithContext[T any](ctx context.Context, manager NetworkManagerV2[T]) (*T, error) {
 	formedEndpoint, queryError := manager.GetEndpoint()
 	if queryError != nil {
-		log.Println("Error in parsing Query")
+		manager.log().Error("Error in parsing query", "error", *queryError)
 		return nil, *queryError
 	}
-	req, err := http.NewRequest("GET", formedEndpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", formedEndpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -105,14 +146,15 @@ func Get[T any](manager NetworkManagerV2[T]) (*T, error) {
 	if manager.apiKey != nil {
 		req.Header.Set("x-api-key", *manager.apiKey)
 	}
-	response, err := manager.client.Do(req)
+	response, err := manager.do(req)
 	if err != nil {
-		log.Printf("Error in making api request: %s", err.Error())
+		manager.log().Error("Error in making api request", "error", err)
+		return nil, err
 	}
 	defer response.Body.Close()
 	if response.StatusCode != http.StatusOK {
 		errorMessage := fmt.Sprintf("Error with the request call: %d", response.StatusCode)
-		log.Println(errorMessage)
+		manager.log().Warn("Error with the request call", "status", response.StatusCode)
 		return nil, utils.GenericError{
 			Message:    errorMessage,
 			StatusCode: response.StatusCode,
@@ -120,12 +162,13 @@ func Get[T any](manager NetworkManagerV2[T]) (*T, error) {
 	}
 	responseBody, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		log.Printf("Error in reading api request: %s", err.Error())
+		manager.log().Error("Error in reading api request", "status", response.StatusCode, "error", err)
+		return nil, err
 	}
 	var result T
 	jsonError := json2.Unmarshal(responseBody, &result)
 	if jsonError != nil {
-		log.Printf("Error in unmarshalling api request: %s", jsonError.Error())
+		manager.log().Error("Error in unmarshalling api request", "status", response.StatusCode, "error", jsonError)
 		return nil, jsonError
 	}
 	return &result, nil
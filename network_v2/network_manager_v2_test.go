@@ -0,0 +1,50 @@
+package network_v2
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type greeting struct {
+	Message string `json:"message"`
+}
+
+func TestGetWithContextJoinsTheCallersTrace(t *testing.T) {
+	traceId := trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceId,
+		SpanID:     trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceFlags: trace.FlagsSampled,
+	})
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		traceparent = request.Header.Get("traceparent")
+		writer.Write([]byte(`{"message":"hello"}`))
+	}))
+	defer server.Close()
+
+	manager := ProvideNetworkManagerV2[greeting](server.URL, nil, nil, nil)
+	result, err := GetWithContext(trace.ContextWithSpanContext(context.Background(), parent), manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Message != "hello" {
+		t.Fatalf("message = %q, want hello", result.Message)
+	}
+	if !strings.Contains(traceparent, traceId.String()) {
+		t.Fatalf("traceparent = %q, want trace %s", traceparent, traceId)
+	}
+}
+
+func TestGetReturnsRequestErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	result, err := Get(ProvideNetworkManagerV2[greeting](server.URL, nil, nil, nil))
+	if err == nil || result != nil {
+		t.Fatalf("Get = %v, %v, want the request error", result, err)
+	}
+}
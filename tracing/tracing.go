@@ -0,0 +1,114 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// InstrumentationName names the tracer and meter of every span and instrument this module creates.
+const InstrumentationName = "github.com/nicholaspark09/awsgorocket"
+
+// Attribute keys follow the OpenTelemetry semantic conventions where one exists.
+const (
+	AttributeTableNames       = attribute.Key("aws.dynamodb.table_names")
+	AttributeConsumedCapacity = attribute.Key("aws.dynamodb.consumed_capacity")
+	AttributeOperation        = attribute.Key("db.operation.name")
+	AttributeDbSystem         = attribute.Key("db.system")
+	AttributeHttpMethod       = attribute.Key("http.request.method")
+	AttributeHttpStatusCode   = attribute.Key("http.response.status_code")
+	AttributeServerAddress    = attribute.Key("server.address")
+	AttributeUrlPath          = attribute.Key("url.path")
+)
+
+// Tracer returns this module's tracer from provider, or from the global provider when provider is nil.
+// The global provider does nothing until the application calls otel.SetTracerProvider, so tracing
+// costs next to nothing for services that do not use it.
+func Tracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(InstrumentationName)
+}
+
+// Context returns ctx, or context.Background() when ctx is nil.
+func Context(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+// RecordError marks span as failed with err. A nil err does nothing.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// Do sends request through client inside a client span and injects the W3C traceparent and
+// tracestate headers, so the server's spans join the caller's trace. Query strings are not recorded,
+// since they often carry keys. Responses of 400 and above mark the span as failed. The span ends once
+// the response body is read to the end, fails or is closed, so it covers the whole transfer.
+func Do(ctx context.Context, provider trace.TracerProvider, client *http.Client, request *http.Request) (*http.Response, error) {
+	ctx, span := Tracer(provider).Start(Context(ctx), "HTTP "+request.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			AttributeHttpMethod.String(request.Method),
+			AttributeServerAddress.String(request.URL.Hostname()),
+			AttributeUrlPath.String(request.URL.Path),
+		),
+	)
+	request = request.WithContext(ctx)
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(request.Header))
+	response, err := client.Do(request)
+	if err != nil {
+		RecordError(span, err)
+		span.End()
+		return nil, err
+	}
+	span.SetAttributes(AttributeHttpStatusCode.Int(response.StatusCode))
+	if response.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, response.Status)
+	}
+	response.Body = &spanBody{body: response.Body, span: span}
+	return response, nil
+}
+
+// spanBody ends its span at the end of the body, on a read error or on Close, whichever comes first.
+type spanBody struct {
+	body  io.ReadCloser
+	span  trace.Span
+	ended sync.Once
+}
+
+func (body *spanBody) Read(buffer []byte) (int, error) {
+	read, err := body.body.Read(buffer)
+	if err == io.EOF {
+		body.end(nil)
+	} else if err != nil {
+		body.end(err)
+	}
+	return read, err
+}
+
+func (body *spanBody) Close() error {
+	err := body.body.Close()
+	body.end(nil)
+	return err
+}
+
+func (body *spanBody) end(err error) {
+	body.ended.Do(func() {
+		RecordError(body.span, err)
+		body.span.End()
+	})
+}
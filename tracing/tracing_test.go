@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type recordingSpan struct {
+	noop.Span
+	mutex  sync.Mutex
+	ends   int
+	status codes.Code
+}
+
+func (span *recordingSpan) End(...trace.SpanEndOption) {
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+	span.ends++
+}
+
+func (span *recordingSpan) SetStatus(code codes.Code, description string) {
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+	span.status = code
+}
+
+func (span *recordingSpan) endCount() int {
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+	return span.ends
+}
+
+type recordingTracerProvider struct {
+	noop.TracerProvider
+	span *recordingSpan
+}
+
+func (provider recordingTracerProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return recordingTracer{span: provider.span}
+}
+
+type recordingTracer struct {
+	noop.Tracer
+	span *recordingSpan
+}
+
+func (tracer recordingTracer) Start(ctx context.Context, _ string, _ ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return trace.ContextWithSpan(ctx, tracer.span), tracer.span
+}
+
+func serve(t *testing.T, status int, body string) *http.Request {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(status)
+		io.WriteString(writer, body)
+	}))
+	t.Cleanup(server.Close)
+	request, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return request
+}
+
+func TestDoEndsSpanAfterBodyIsRead(t *testing.T) {
+	span := &recordingSpan{}
+	response, err := Do(context.Background(), recordingTracerProvider{span: span}, http.DefaultClient, serve(t, http.StatusOK, "hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if span.endCount() != 0 {
+		t.Fatal("span ended before the body was read")
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil || string(body) != "hello" {
+		t.Fatalf("body = %q, %v", body, err)
+	}
+	if span.endCount() != 1 {
+		t.Fatalf("span ended %d times after reading the body, want 1", span.endCount())
+	}
+	response.Body.Close()
+	if span.endCount() != 1 {
+		t.Fatalf("span ended %d times after closing the body, want 1", span.endCount())
+	}
+}
+
+func TestDoEndsSpanWhenBodyIsClosedUnread(t *testing.T) {
+	span := &recordingSpan{}
+	response, err := Do(context.Background(), recordingTracerProvider{span: span}, http.DefaultClient, serve(t, http.StatusNotFound, "missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if span.endCount() != 1 {
+		t.Fatalf("span ended %d times, want 1", span.endCount())
+	}
+	if span.status != codes.Error {
+		t.Fatalf("status = %v, want an error for a 404", span.status)
+	}
+}
+
+func TestDoEndsSpanWhenRequestFails(t *testing.T) {
+	span := &recordingSpan{}
+	request := serve(t, http.StatusOK, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Do(ctx, recordingTracerProvider{span: span}, http.DefaultClient, request); err == nil {
+		t.Fatal("expected the cancelled request to fail")
+	}
+	if span.endCount() != 1 || span.status != codes.Error {
+		t.Fatalf("span ended %d times with status %v, want 1 and an error", span.endCount(), span.status)
+	}
+}
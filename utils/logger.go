@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"context"
+	"log/slog"
+)
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool   { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error  { return nil }
+func (handler discardHandler) WithAttrs([]slog.Attr) slog.Handler { return handler }
+func (handler discardHandler) WithGroup(string) slog.Handler      { return handler }
+
+var nopLogger = slog.New(discardHandler{})
+
+// NopLogger drops everything. Libraries in this module log to it unless given a logger.
+func NopLogger() *slog.Logger {
+	return nopLogger
+}
+
+// LoggerOrNop returns logger, or NopLogger when it is nil.
+func LoggerOrNop(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return nopLogger
+	}
+	return logger
+}